@@ -5,6 +5,7 @@
 package console
 
 import (
+	"console/terminfo"
 	"strconv"
 )
 
@@ -33,9 +34,48 @@ const (
 	WHITE
 )
 
-// Color defines a VT100-compatible colour.
+// ColorValue is a single foreground or background colour, in whatever form
+// it was requested: the terminal's default, one of the 8 basic ANSI
+// colours, an index into the terminal's 256-colour palette, or a 24-bit RGB
+// triple. The zero value of an interface holding no ColorValue is treated
+// the same as ColorDefault.
+type ColorValue interface {
+	colorValue()
+}
+
+// ColorDefault selects the terminal's default foreground or background
+// colour.
+type ColorDefault struct{}
+
+func (ColorDefault) colorValue() {}
+
+// ColorANSI selects one of the 8 basic ANSI colours (see BLACK..WHITE).
+type ColorANSI uint8
+
+func (ColorANSI) colorValue() {}
+
+// Color256 selects a colour from the terminal's 256-colour palette.
+type Color256 uint8
+
+func (Color256) colorValue() {}
+
+// ColorRGB selects a 24-bit truecolour value.
+type ColorRGB struct {
+	R, G, B uint8
+}
+
+func (ColorRGB) colorValue() {}
+
+// RGB returns a ColorRGB value for the given components.
+func RGB(r, g, b uint8) ColorRGB {
+	return ColorRGB{r, g, b}
+}
+
+// Color defines the style of a single Cell: an attribute and a foreground
+// and background ColorValue.
 type Color struct {
-	Attr, Fore, Back uint8
+	Attr       uint8
+	Fore, Back ColorValue
 }
 
 // String returns an escape sequence representing the Color.
@@ -43,23 +83,71 @@ func (c Color) String() string {
 	return FormatColor(c.Attr, c.Fore, c.Back)
 }
 
-// FormatColor returns an escape sequence representing the style defined by the
-// attribute attr, foreground colour fore, and background colour back.
-func FormatColor(attr, fore, back uint8) string {
-	if attr < 0 || attr > 8 {
+// FormatColor returns an escape sequence representing the style defined by
+// the attribute attr, foreground colour fore, and background colour back.
+// ColorANSI values are emitted through the active terminal's setaf/setab
+// capabilities (see the terminfo package); Color256 and ColorRGB values are
+// emitted as indexed or truecolour SGR sequences (38/48;5;N and
+// 38/48;2;R;G;B); ColorDefault, and a nil ColorValue, reset the
+// corresponding half to the terminal's default (39/49).
+func FormatColor(attr uint8, fore, back ColorValue) string {
+	if attr > 8 {
 		panic("colorString: invalid attribute")
 	}
-	if fore < 0 || fore > 7 {
-		panic("colorString: invalid foreground colour")
+
+	return _CSI + strconv.FormatInt(int64(attr), 10) + "m" +
+		formatColorValue(true, fore) +
+		formatColorValue(false, back)
+}
+
+// formatColorValue renders one half (foreground if isFg, else background)
+// of a colour escape for v.
+func formatColorValue(isFg bool, v ColorValue) string {
+	var fgCode, defaultCode = 38, 39
+	if !isFg {
+		fgCode, defaultCode = 48, 49
+	}
+
+	switch t := v.(type) {
+	case ColorANSI:
+		if isFg {
+			return terminfo.Eval(caps.Setaf, int(t))
+		}
+		return terminfo.Eval(caps.Setab, int(t))
+	case Color256:
+		return _CSI + strconv.Itoa(fgCode) + ";5;" + strconv.Itoa(int(t)) + "m"
+	case ColorRGB:
+		return _CSI + strconv.Itoa(fgCode) + ";2;" +
+			strconv.Itoa(int(t.R)) + ";" + strconv.Itoa(int(t.G)) + ";" + strconv.Itoa(int(t.B)) + "m"
+	default:
+		return _CSI + strconv.Itoa(defaultCode) + "m"
 	}
-	if back < 0 || back > 7 {
-		panic("colorString: invalid background colour")
+}
+
+// cubeSteps are the 6 intensity levels used by each axis of the xterm
+// 256-colour palette's 6x6x6 colour cube (indices 16-231).
+var cubeSteps = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// nearest256 maps an RGB colour to the index of the closest colour in the
+// xterm 256-colour palette's 6x6x6 colour cube.
+func nearest256(c ColorRGB) Color256 {
+	nearestStep := func(v uint8) int {
+		var best, bestDiff = 0, 256
+		for i, s := range cubeSteps {
+			if diff := absInt(int(v) - int(s)); diff < bestDiff {
+				best, bestDiff = i, diff
+			}
+		}
+		return best
 	}
-	var (
-		astring = strconv.FormatInt(int64(attr), 10)
-		fstring = strconv.FormatInt(int64(fore+30), 10)
-		bstring = strconv.FormatInt(int64(back+40), 10)
-	)
-	return _CSI + astring + ";" + fstring + ";" + bstring + "m"
+
+	r, g, b := nearestStep(c.R), nearestStep(c.G), nearestStep(c.B)
+	return Color256(16 + 36*r + 6*g + b)
 }
 
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import "testing"
+
+// TestNearest256 checks that nearest256 maps RGB corners and a few
+// in-between values to the expected index in the 6x6x6 colour cube.
+func TestNearest256(t *testing.T) {
+	tests := []struct {
+		in   ColorRGB
+		want Color256
+	}{
+		{ColorRGB{0, 0, 0}, 16},
+		{ColorRGB{255, 255, 255}, 231},
+		{ColorRGB{255, 0, 0}, 16 + 36*5},
+		{ColorRGB{0, 255, 0}, 16 + 6*5},
+		{ColorRGB{0, 0, 255}, 16 + 5},
+		{ColorRGB{100, 100, 100}, 16 + 36*1 + 6*1 + 1},
+	}
+
+	for _, tt := range tests {
+		if got := nearest256(tt.in); got != tt.want {
+			t.Errorf("nearest256(%+v) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
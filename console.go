@@ -2,72 +2,134 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Package console defines an API for manipulating a terminal. Currently it is
-// built around VT100 escape sequences, though it is conceivable that the API
-// may be implemented to work with other terminals.
+// Package console defines an API for manipulating a terminal. Escape
+// sequences are looked up from the terminfo capability table for $TERM (see
+// the terminfo subpackage), with a compiled-in fallback for terminals
+// commonly found in the wild, so the package is not tied to any one
+// terminal's behaviour.
 //
 // TODO: Provide a means to accept Unicode input.
 package console
 
 import (
+	"console/terminfo"
 	"fmt"
 	"io"
-	"strconv"
+	"os"
+	"sync"
 	"unicode/utf8"
 )
 
 const (
-	_ESC  = "\033"
-	_CSI  = _ESC + "["
-	clear = _CSI + "2J"
+	_ESC = "\033"
+	_CSI = _ESC + "["
 )
 
+// caps holds the capabilities of the terminal Console is driving. It
+// defaults to a compiled-in xterm-compatible table and is replaced by Init
+// with one loaded for $TERM.
+var caps = terminfo.Fallback("xterm")
+
+// nativeMode is true when Console must bypass escape sequences entirely and
+// drive the legacy Win32 console APIs directly, because the attached
+// console predates ENABLE_VIRTUAL_TERMINAL_PROCESSING/_INPUT support. It is
+// only ever set on Windows, by termios_windows.go's (*termios).set; it is
+// always false elsewhere.
+var nativeMode bool
+
 // Console is an interface to a terminal, defined by an input stream and an
 // output stream.
 type Console struct {
 	in  io.Reader
 	out io.Writer
+
+	// events and pumpStart back PollEvent; see startPump.
+	events    chan Event
+	pumpStart sync.Once
+
+	mode OutputMode
 }
 
 // New returns a Console that receives input from Reader in and outputs to
-// Writer out.
+// Writer out. Its OutputMode defaults to the result of DetectOutputMode.
 func New(in io.Reader, out io.Writer) *Console {
-	return &Console{in: in, out: out}
+	return &Console{in: in, out: out, mode: DetectOutputMode()}
+}
+
+// NativeMode reports whether c is driving a pre-VT Windows console
+// directly through the Win32 console APIs rather than escape sequences.
+// Code that builds its own escape sequences instead of going through a
+// Console's methods - frame.Renderer, for instance - must check this and
+// fall back to issuing Console calls (MoveTo, SetColor, WriteRune, ...)
+// per cell instead, since nativeMode has no escape-sequence parser to
+// interpret raw bytes written through WriteString.
+func (c *Console) NativeMode() bool {
+	return nativeMode
 }
 
 // Clear writes the clear escape sequence.
 func (c *Console) Clear() {
-	c.out.Write([]byte(clear))
+	if nativeMode {
+		nativeClear()
+		return
+	}
+	c.out.Write([]byte(FormatClear()))
 }
 
 // MoveUp moves the cursor up by i spaces.
 func (c *Console) MoveUp(i int) {
+	if nativeMode {
+		nativeMoveUp(i)
+		return
+	}
 	c.out.Write([]byte(FormatMoveUp(i)))
 }
 
 // MoveUp moves the cursor down by i spaces.
 func (c *Console) MoveDown(i int) {
+	if nativeMode {
+		nativeMoveDown(i)
+		return
+	}
 	c.out.Write([]byte(FormatMoveDown(i)))
 }
 
 // MoveUp moves the cursor right by i spaces.
 func (c *Console) MoveRight(i int) {
+	if nativeMode {
+		nativeMoveRight(i)
+		return
+	}
 	c.out.Write([]byte(FormatMoveRight(i)))
 }
 
 // MoveUp moves the cursor left by i spaces.
 func (c *Console) MoveLeft(i int) {
+	if nativeMode {
+		nativeMoveLeft(i)
+		return
+	}
 	c.out.Write([]byte(FormatMoveLeft(i)))
 }
 
 // MoveTo moves the cursor to the specified line and column.
 func (c *Console) MoveTo(line, column int) {
+	if nativeMode {
+		nativeMoveTo(line, column)
+		return
+	}
 	c.out.Write([]byte(FormatMoveTo(line, column)))
 }
 
-// SetColor sets the current printing colour to col.
+// SetColor sets the current printing colour to col, clamping it to what
+// c's OutputMode allows.
 func (c *Console) SetColor(col Color) {
-	c.out.Write([]byte(col.String()))
+	var clamped = c.Clamp(col)
+	if nativeMode {
+		nativeSetColor(clamped)
+		return
+	}
+	c.out.Write([]byte(clamped.String()))
 }
 
 // PutRune writes the Unicode rune r to the specified line and column.
@@ -78,6 +140,11 @@ func (c *Console) PutRune(line, column int, r rune) {
 
 // WriteRune writes the Unicode rune r to the current cursor location.
 func (c *Console) WriteRune(r rune) {
+	if nativeMode {
+		nativeWriteString(string(r))
+		return
+	}
+
 	var (
 		bytes [4]byte
 		l     int
@@ -88,108 +155,140 @@ func (c *Console) WriteRune(r rune) {
 
 // PutString writes the string str to the specified line and column.
 func (c *Console) PutString(line, column int, str string) {
-	c.out.Write([]byte(FormatMoveTo(line, column) + str))
+	c.MoveTo(line, column)
+	c.WriteString(str)
 }
 
 // PutStringf calls fmt.Sprintf to format the string s with arguments args and
 // writes the result to the specified line and column.
 func (c *Console) PutStringf(line, column int, s string, args ...interface{}) {
-	c.out.Write([]byte(FormatMoveTo(line, column) + fmt.Sprintf(s, args...)))
+	c.MoveTo(line, column)
+	c.WriteStringf(s, args...)
 }
 
 // WriteString writes the string str to the current cursor location.
 func (c *Console) WriteString(str string) {
+	if nativeMode {
+		nativeWriteString(str)
+		return
+	}
 	c.out.Write([]byte(str))
 }
 
 // WriteStringf calls fmt.Sprintf to format the string s with arguments args and
 // writes the result to the current cursor location.
 func (c *Console) WriteStringf(s string, args ...interface{}) {
-	c.out.Write([]byte(fmt.Sprintf(s, args...)))
+	c.WriteString(fmt.Sprintf(s, args...))
 }
 
 // HideCursor prevents the terminal from rendering the cursor.
 func (c *Console) HideCursor() {
-	c.out.Write([]byte(_CSI + "?25l"))
+	if nativeMode {
+		nativeSetCursorVisible(false)
+		return
+	}
+	c.out.Write([]byte(caps.Civis))
 }
 
 // ShowCursor permits the terminal to render the cursor
 func (c *Console) ShowCursor() {
-	c.out.Write([]byte(_CSI + "?25h"))
-}
-
-// keybuf is a buffer for reading input.
-var keybuf [16]byte
-
-// GetKey reads a keystroke from the Console's input stream and returns its key
-// code. There is no current support for reading Unicode runes.
-func (c *Console) GetKey() int32 {
-	n, _ := c.in.Read(keybuf[:])
-
-	return parseKey(keybuf[:n])
+	if nativeMode {
+		nativeSetCursorVisible(true)
+		return
+	}
+	c.out.Write([]byte(caps.Cnorm))
 }
 
-// AltBuffer switches to the alternate terminal buffer.
+// AltBuffer switches to the alternate terminal buffer. It is a no-op in
+// nativeMode: the legacy consoles that lack virtual terminal support have
+// no equivalent of a second screen buffer reachable through escape
+// sequences.
 func (c *Console) AltBuffer() {
-	c.out.Write([]byte(_CSI + "?47h"))
+	if nativeMode {
+		return
+	}
+	c.out.Write([]byte(caps.Smcup))
 }
 
-// MainBuffer switches to the main terminal buffer.
+// MainBuffer switches to the main terminal buffer. See AltBuffer.
 func (c *Console) MainBuffer() {
-	c.out.Write([]byte(_CSI + "?47l"))
+	if nativeMode {
+		return
+	}
+	c.out.Write([]byte(caps.Rmcup))
 }
 
 // FormatClear returns the escape sequence that clears the terminal
 func FormatClear() string {
-	return clear
+	return caps.Clear
 }
 
 // FormateMoveUp returns the escape sequence that moves the cursor up i spaces.
 func FormatMoveUp(i int) string {
-	var istring = strconv.FormatInt(int64(i), 10)
-	return _CSI + istring + "A"
+	return terminfo.Eval(caps.Cuu, i)
 }
 
 // FormateMoveDown returns the escape sequence that moves the cursor down i
 // spaces.
 func FormatMoveDown(i int) string {
-	var istring = strconv.FormatInt(int64(i), 10)
-	return _CSI + istring + "B"
+	return terminfo.Eval(caps.Cud, i)
 }
 
 // FormateMoveRight returns the escape sequence that moves the cursor right i
 // spaces.
 func FormatMoveRight(i int) string {
-	var istring = strconv.FormatInt(int64(i), 10)
-	return _CSI + istring + "C"
+	return terminfo.Eval(caps.Cuf, i)
 }
 
 // FormateMoveLeft returns the escape sequence that moves the cursor left i
 // spaces.
 func FormatMoveLeft(i int) string {
-	var istring = strconv.FormatInt(int64(i), 10)
-	return _CSI + istring + "D"
+	return terminfo.Eval(caps.Cub, i)
 }
 
 // FormatMoveTo returns the escape sequence that moves the cursor to the
 // specified line and column.
 func FormatMoveTo(line, column int) string {
-	var (
-		lstring = strconv.FormatInt(int64(line), 10)
-		cstring = strconv.FormatInt(int64(column), 10)
-	)
-	return _CSI + lstring + ";" + cstring + "H"
+	return terminfo.Eval(caps.Cup, line-1, column-1)
 }
 
-// Init initializes the terminal to a suitable mode.
+// FormatEl returns the escape sequence that erases from the cursor to the
+// end of the current line.
+func FormatEl() string {
+	return caps.El
+}
+
+// Init initializes the terminal to a suitable mode and loads the capability
+// table for $TERM, falling back to a compiled-in table if no terminfo
+// database entry can be found for it.
 func Init() error {
 	var (
 		term *termios
-		err error
+		err  error
 	)
-	if term, err = getTermios(); err != nil { return err }
+	if term, err = getTermios(); err != nil {
+		return err
+	}
 	term.rawMode()
-	return term.set()
+	if err = term.set(); err != nil {
+		return err
+	}
+
+	caps = loadCaps()
+	return nil
+}
+
+// loadCaps resolves the capability table for the terminal named by $TERM.
+func loadCaps() *terminfo.Terminfo {
+	name := os.Getenv("TERM")
+	if name == "" {
+		name = "xterm"
+	}
+
+	if t, err := terminfo.Load(name); err == nil {
+		return t
+	}
+	return terminfo.Fallback(name)
 }
 
 // Exit returns the terminal to its default settings.
@@ -0,0 +1,197 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import (
+	"io"
+	"time"
+)
+
+// escTimeout is how long readLoop waits after a lone ESC byte before
+// deciding it is a standalone Escape keypress rather than the start of a
+// longer escape sequence.
+const escTimeout = 25 * time.Millisecond
+
+// EventType identifies what kind of occurrence an Event describes.
+type EventType uint8
+
+const (
+	EventKey EventType = iota
+	EventMouse
+	EventResize
+	EventError
+)
+
+// Modifier is a bitmask of the modifier keys held during a key or mouse
+// Event.
+type Modifier uint8
+
+const (
+	ModShift Modifier = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// MouseButton identifies the button reported by a mouse Event.
+type MouseButton uint8
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	MouseRelease
+	MouseWheelUp
+	MouseWheelDown
+)
+
+// Event describes a single occurrence read from a Console's input stream:
+// a keystroke, a mouse action, a terminal resize, or a read error.
+type Event struct {
+	Type EventType
+
+	// Key is set for non-printable keys (the K_* constants); Ch is set for
+	// printable runes. For a recognised key exactly one of the two is
+	// non-zero; an escape sequence the parser does not recognise is
+	// reported as an EventKey with both zero.
+	Key int32
+	Ch  rune
+	Mod Modifier
+
+	MouseX, MouseY int
+	MouseButton    MouseButton
+
+	// Moving is set on an EventMouse report that carries motion (the SGR/
+	// X10 "button-event tracking" bit, or MOUSE_MOVED on Windows) rather
+	// than a fresh press or release, so callers can tell a drag from a
+	// click: the same MouseButton value is reported either way.
+	Moving bool
+
+	Width, Height int
+
+	Err error
+}
+
+// PollEvent blocks until an event occurs on c's input stream and returns
+// it. The first call starts a background goroutine that reads and decodes
+// the stream into Events, and another that watches for terminal resizes.
+func (c *Console) PollEvent() Event {
+	c.pumpStart.Do(c.startPump)
+	return <-c.events
+}
+
+// startPump launches the goroutines backing PollEvent. It is called at
+// most once per Console, via pumpStart. In nativeMode, events are decoded
+// from Win32 INPUT_RECORDs instead of parsed from the input byte stream;
+// see readLoopNative.
+func (c *Console) startPump() {
+	c.events = make(chan Event)
+	if nativeMode {
+		go c.readLoopNative()
+	} else {
+		go c.readLoop()
+	}
+	go c.watchResize()
+}
+
+// readLoop decodes Events from c's input stream and sends them to
+// c.events until the stream returns an error.
+func (c *Console) readLoop() {
+	var (
+		raw = make(chan byte)
+		buf []byte
+	)
+	go readBytes(c.in, raw)
+
+	for {
+		b, ok := <-raw
+		if !ok {
+			c.events <- Event{Type: EventError, Err: io.EOF}
+			return
+		}
+		buf = append(buf, b)
+
+		if len(buf) == 1 && buf[0] == '\033' {
+			select {
+			case b, ok := <-raw:
+				if !ok {
+					c.events <- Event{Type: EventKey, Key: K_ESCAPE}
+					c.events <- Event{Type: EventError, Err: io.EOF}
+					return
+				}
+				buf = append(buf, b)
+			case <-time.After(escTimeout):
+				c.events <- Event{Type: EventKey, Key: K_ESCAPE}
+				buf = buf[:0]
+				continue
+			}
+		}
+
+		for len(buf) > 0 {
+			n, ev := parseEvent(buf)
+			if n == 0 {
+				break
+			}
+			c.events <- ev
+			buf = buf[n:]
+		}
+	}
+}
+
+// readBytes copies bytes one at a time from in to raw, closing raw once in
+// returns an error.
+func readBytes(in io.Reader, raw chan<- byte) {
+	var b [1]byte
+	for {
+		n, err := in.Read(b[:])
+		if n > 0 {
+			raw <- b[0]
+		}
+		if err != nil {
+			close(raw)
+			return
+		}
+	}
+}
+
+// GetKey reads a keystroke from the Console's input stream and returns its
+// key code, or for a printable key its Unicode rune. Modifiers are not
+// reported.
+//
+// Deprecated: use PollEvent, which also reports mouse and resize events.
+func (c *Console) GetKey() int32 {
+	for {
+		switch ev := c.PollEvent(); ev.Type {
+		case EventKey:
+			if ev.Key != 0 {
+				return ev.Key
+			}
+			return int32(ev.Ch)
+		case EventError:
+			return 0
+		}
+	}
+}
+
+// EnableMouse turns on mouse reporting: SGR reporting with button-event
+// tracking on a VT-capable terminal, so that motion while a button is held
+// is reported as drag events alongside plain presses, releases and wheel;
+// or, in nativeMode, the ENABLE_MOUSE_INPUT console mode bit that makes
+// ReadConsoleInputW deliver MOUSE_EVENT_RECORDs in the first place.
+func (c *Console) EnableMouse() {
+	if nativeMode {
+		nativeSetMouseInput(true)
+		return
+	}
+	c.out.Write([]byte(_CSI + "?1002;1006h"))
+}
+
+// DisableMouse turns off mouse reporting. See EnableMouse.
+func (c *Console) DisableMouse() {
+	if nativeMode {
+		nativeSetMouseInput(false)
+		return
+	}
+	c.out.Write([]byte(_CSI + "?1002;1006l"))
+}
@@ -0,0 +1,191 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frame
+
+import (
+	"console"
+	"image"
+	"unicode/utf8"
+)
+
+// Renderer performs differential output of a Frame to a Console. It keeps a
+// front buffer holding the contents of the last Flush alongside the Frame's
+// own Data, which acts as the back buffer that callers draw into. Flush
+// compares the two and writes only the Cells that changed, rather than
+// reprinting the whole Frame every time.
+type Renderer struct {
+	// Back is the Frame callers draw into using the usual Frame methods.
+	Back *Frame
+
+	front []Cell
+	full  bool
+}
+
+// NewRenderer creates a Renderer with a back buffer bounded by r.
+func NewRenderer(r image.Rectangle) *Renderer {
+	return &Renderer{
+		Back:  New(r),
+		front: make([]Cell, r.Dx()*r.Dy()),
+		full:  true,
+	}
+}
+
+// Resize reallocates the Renderer's buffers to the rectangle r, discarding
+// their contents. The next call to Flush will perform a full repaint.
+func (rd *Renderer) Resize(r image.Rectangle) {
+	rd.Back = New(r)
+	rd.front = make([]Cell, r.Dx()*r.Dy())
+	rd.full = true
+}
+
+// A reusable buffer meant to minimize future allocations.
+var flushBuf []byte
+
+// Flush writes to c only the Cells of the back buffer that differ from the
+// front buffer, moving the cursor only when the previous write did not
+// already leave it in the right place, and re-emitting the colour escape
+// only when the Cell's Color, clamped to c's OutputMode, differs from the
+// last one written during this Flush (the first Cell written by each Flush
+// always emits its colour). Trailing blank Cells at the end of a row are cleared
+// with a single erase-in-line sequence rather than being printed
+// individually. The first Flush after construction or a Resize always
+// performs a full repaint.
+//
+// If c is in native mode, Flush instead writes each changed Cell through
+// c's own MoveTo/SetColor/WriteRune methods: c has no escape-sequence
+// parser to interpret a buffer of raw escapes built up here.
+func (rd *Renderer) Flush(c *console.Console) {
+	if c.NativeMode() {
+		rd.flushNative(c)
+		return
+	}
+
+	var (
+		b          = rd.Back.Bounds
+		col        console.Color
+		haveCol    bool
+		curX, curY int
+		havePos    bool
+	)
+
+	flushBuf = flushBuf[:0]
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		var tail = rd.trailingBlank(y)
+
+		for x := b.Min.X; x < tail; x++ {
+			var (
+				idx  = rd.Back.CellOffset(x, y)
+				cell = rd.Back.Data[idx]
+			)
+			if cell.R == 0 {
+				cell.R = ' '
+			}
+
+			if !rd.full && cell == rd.front[idx] {
+				continue
+			}
+
+			if !havePos || curX != x || curY != y {
+				flushBuf = append(flushBuf, console.FormatMoveTo(y+1, x+1)...)
+			}
+
+			var clamped = c.Clamp(cell.C)
+			if !haveCol || col != clamped {
+				col = clamped
+				haveCol = true
+				flushBuf = append(flushBuf, col.String()...)
+			}
+
+			var buf [4]byte
+			n := utf8.EncodeRune(buf[:], cell.R)
+			flushBuf = append(flushBuf, buf[:n]...)
+
+			rd.front[idx] = cell
+			curX, curY, havePos = x+1, y, true
+		}
+
+		if tail < b.Max.X && rd.rowTailDirty(y, tail) {
+			flushBuf = append(flushBuf, console.FormatMoveTo(y+1, tail+1)...)
+			flushBuf = append(flushBuf, console.FormatEl()...)
+			havePos = false
+
+			for x := tail; x < b.Max.X; x++ {
+				rd.front[rd.Back.CellOffset(x, y)] = Cell{' ', console.Color{}}
+			}
+		}
+	}
+
+	rd.full = false
+	c.WriteString(string(flushBuf))
+}
+
+// flushNative is Flush's native-mode path: c has no escape-sequence parser,
+// so changed Cells are written one at a time through c's own methods rather
+// than through a buffer of concatenated escapes.
+func (rd *Renderer) flushNative(c *console.Console) {
+	var b = rd.Back.Bounds
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var (
+				idx  = rd.Back.CellOffset(x, y)
+				cell = rd.Back.Data[idx]
+			)
+			if cell.R == 0 {
+				cell.R = ' '
+			}
+
+			if !rd.full && cell == rd.front[idx] {
+				continue
+			}
+
+			c.MoveTo(y+1, x+1)
+			c.SetColor(cell.C)
+			c.WriteRune(cell.R)
+
+			rd.front[idx] = cell
+		}
+	}
+
+	rd.full = false
+}
+
+// trailingBlank returns the column at which a run of blank Cells (a space
+// rune with the default Color) extends to the end of row y.
+func (rd *Renderer) trailingBlank(y int) int {
+	var b = rd.Back.Bounds
+
+	x := b.Max.X
+	for x > b.Min.X {
+		var cell = rd.Back.Data[rd.Back.CellOffset(x-1, y)]
+		if cell.R != 0 && cell.R != ' ' {
+			break
+		}
+		if cell.C != (console.Color{}) {
+			break
+		}
+		x--
+	}
+
+	return x
+}
+
+// rowTailDirty reports whether the front buffer's copy of row y, from
+// column from to the end of the Frame, is not already blank.
+func (rd *Renderer) rowTailDirty(y, from int) bool {
+	if rd.full {
+		return true
+	}
+
+	var b = rd.Back.Bounds
+	for x := from; x < b.Max.X; x++ {
+		if rd.front[rd.Back.CellOffset(x, y)] != (Cell{' ', console.Color{}}) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frame
+
+import (
+	"bytes"
+	"console"
+	"image"
+	"testing"
+)
+
+// TestRendererFlush exercises the differential output logic across a
+// sequence of Flushes: the first performs a full repaint with coalesced
+// cursor moves and a single colour emit, the second emits nothing because
+// nothing changed, and the third touches only the single Cell that changed.
+func TestRendererFlush(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		c   = console.New(nil, &buf)
+	)
+	c.SetOutputMode(console.OutputNormal)
+
+	rd := NewRenderer(image.Rect(0, 0, 5, 1))
+	rd.Back.Set(0, 0, Cell{R: 'a'})
+	rd.Back.Set(1, 0, Cell{R: 'b'})
+	rd.Back.Set(2, 0, Cell{R: 'c'})
+
+	var col console.Color
+	rd.Flush(c)
+
+	want := console.FormatMoveTo(1, 1) + col.String() + "a" + "b" + "c" +
+		console.FormatMoveTo(1, 4) + console.FormatEl()
+	if got := buf.String(); got != want {
+		t.Fatalf("first Flush = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	rd.Flush(c)
+	if got := buf.String(); got != "" {
+		t.Fatalf("unchanged Flush = %q, want empty", got)
+	}
+
+	buf.Reset()
+	rd.Back.Set(4, 0, Cell{R: 'z'})
+	rd.Flush(c)
+
+	want = console.FormatMoveTo(1, 5) + col.String() + "z"
+	if got := buf.String(); got != want {
+		t.Fatalf("single-Cell Flush = %q, want %q", got, want)
+	}
+}
+
+// TestRendererResizeForcesFullRepaint checks that Resize discards the front
+// buffer so the next Flush repaints every Cell, even ones that would
+// otherwise look unchanged.
+func TestRendererResizeForcesFullRepaint(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		c   = console.New(nil, &buf)
+	)
+	c.SetOutputMode(console.OutputNormal)
+
+	rd := NewRenderer(image.Rect(0, 0, 1, 1))
+	rd.Back.Set(0, 0, Cell{R: 'x'})
+	rd.Flush(c)
+
+	rd.Resize(image.Rect(0, 0, 1, 1))
+	rd.Back.Set(0, 0, Cell{R: 'x'})
+
+	buf.Reset()
+	rd.Flush(c)
+	if got := buf.String(); got == "" {
+		t.Fatal("Flush after Resize produced no output, want a full repaint")
+	}
+}
@@ -5,6 +5,9 @@
 package console
 
 import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Key codes. Unprintable codes are given values within the bounds of the
@@ -31,81 +34,291 @@ const (
 	K_KPPAGEDOWN
 )
 
-func parseSS3(buf []byte) int32 {
-	if len(buf) < 1 {
-		return 0
+// parseEvent attempts to decode a single Event from the start of buf, which
+// holds the bytes read so far from the input stream. It returns the number
+// of bytes consumed and the Event they decoded to. A consumed count of 0
+// means buf is a prefix of a longer sequence and the caller should wait for
+// more bytes before calling parseEvent again.
+func parseEvent(buf []byte) (int, Event) {
+	if len(buf) == 0 {
+		return 0, Event{}
+	}
+
+	switch {
+	case buf[0] == '\033':
+		return parseESC(buf)
+	case buf[0] == K_TAB || buf[0] == K_ENTER:
+		return 1, Event{Type: EventKey, Key: int32(buf[0])}
+	case buf[0] >= 1 && buf[0] <= 26:
+		return 1, Event{Type: EventKey, Ch: rune('a' + buf[0] - 1), Mod: ModCtrl}
+	case buf[0] == K_BACKSPACE:
+		return 1, Event{Type: EventKey, Key: K_BACKSPACE}
+	}
+
+	r, size := utf8.DecodeRune(buf)
+	if r == utf8.RuneError && size <= 1 {
+		if !utf8.FullRune(buf) && len(buf) < utf8.UTFMax {
+			return 0, Event{}
+		}
+		return 1, Event{Type: EventKey, Key: int32(buf[0])}
+	}
+
+	return size, Event{Type: EventKey, Ch: r}
+}
+
+// parseESC decodes a sequence starting with the ESC byte: a CSI sequence, an
+// SS3 sequence, or Alt plus a printable rune. Callers are expected to treat
+// a lone, otherwise-unfollowed ESC specially (see Console.readLoop), since
+// that can't be distinguished from the start of a longer sequence by
+// looking at the bytes alone.
+func parseESC(buf []byte) (int, Event) {
+	if len(buf) < 2 {
+		return 0, Event{}
+	}
+
+	switch buf[1] {
+	case '[':
+		return parseCSI(buf)
+	case 'O':
+		return parseSS3(buf)
+	}
+
+	r, size := utf8.DecodeRune(buf[1:])
+	if r == utf8.RuneError && size <= 1 {
+		if !utf8.FullRune(buf[1:]) && len(buf) < 1+utf8.UTFMax {
+			return 0, Event{}
+		}
+		return 2, Event{Type: EventKey, Key: int32(buf[1]), Mod: ModAlt}
 	}
 
-	switch buf[0] {
+	return 1 + size, Event{Type: EventKey, Ch: r, Mod: ModAlt}
+}
+
+// parseSS3 decodes an SS3 sequence (ESC O <letter>), used by some terminals
+// for the cursor keys and Home/End when in application keypad mode.
+func parseSS3(buf []byte) (int, Event) {
+	if len(buf) < 3 {
+		return 0, Event{}
+	}
+
+	var key int32
+	switch buf[2] {
+	case 'A':
+		key = K_UP
+	case 'B':
+		key = K_DOWN
+	case 'C':
+		key = K_RIGHT
+	case 'D':
+		key = K_LEFT
 	case 'H':
-		return K_HOME
+		key = K_HOME
 	case 'F':
-		return K_END
+		key = K_END
 	default:
-		return 0
+		return 3, Event{}
 	}
 
-	panic("unreachable")
+	return 3, Event{Type: EventKey, Key: key}
 }
 
-func parseCSI(buf []byte) int32 {
-	if len(buf) < 1 {
-		return 0
+// parseCSI decodes a CSI sequence (ESC [ ...): cursor and navigation keys,
+// modified keys of the form "1;<mod><letter>" where mod-1 is a bitmask of
+// Shift(1)/Alt(2)/Ctrl(4), SGR mouse reports ("<b;x;yM"/"m"), and legacy X10
+// mouse reports ("M" followed by three raw bytes).
+func parseCSI(buf []byte) (int, Event) {
+	if len(buf) < 3 {
+		return 0, Event{}
+	}
+
+	if buf[2] == 'M' {
+		if len(buf) < 6 {
+			return 0, Event{}
+		}
+		return 6, parseX10Mouse(buf[3:6])
 	}
 
-	switch string(buf) {
-	case "A":
-		return K_UP
-	case "B":
-		return K_DOWN
-	case "C":
-		return K_RIGHT
-	case "D":
-		return K_LEFT
-	case "2~":
-		return K_INSERT
-	case "3~":
-		return K_DELETE
-	case "5~":
-		return K_PAGEUP
-	case "6~":
-		return K_PAGEDOWN
+	i := 2
+	for i < len(buf) && (buf[i] == '<' || buf[i] == ';' || (buf[i] >= '0' && buf[i] <= '9')) {
+		i++
+	}
+	if i >= len(buf) {
+		return 0, Event{}
+	}
+
+	var (
+		final = buf[i]
+		seq   = buf[2:i]
+	)
+
+	switch {
+	case len(seq) > 0 && seq[0] == '<':
+		return i + 1, parseSGRMouse(seq[1:], final)
+	case final == '~':
+		return i + 1, parseTilde(seq)
 	default:
-		return 0
+		return i + 1, parseCursorKey(seq, final)
 	}
+}
 
-	panic("unreachable")
+// modifierFromParam parses the modifier parameter that follows the ';' in a
+// modified key or tilde-terminated sequence, returning ModShift/ModAlt/
+// ModCtrl combined as described by CSI u (mod-1 is the bitmask).
+func modifierFromParam(s string) Modifier {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0
+	}
+	return Modifier(n - 1)
 }
 
-func parseESC(buf []byte) int32 {
-	if len(buf) < 1 {
-		return K_ESCAPE
+// parseCursorKey decodes a plain or modified arrow/Home/End sequence. seq is
+// the portion between "CSI" and the final letter, either empty or
+// "1;<mod>".
+func parseCursorKey(seq []byte, final byte) Event {
+	var (
+		s   = string(seq)
+		mod Modifier
+	)
+	if idx := strings.IndexByte(s, ';'); idx >= 0 {
+		mod = modifierFromParam(s[idx+1:])
 	}
 
-	switch buf[0] {
-	case '[':
-		return parseCSI(buf[1:])
-	case 'O':
-		return parseSS3(buf[1:])
+	var key int32
+	switch final {
+	case 'A':
+		key = K_UP
+	case 'B':
+		key = K_DOWN
+	case 'C':
+		key = K_RIGHT
+	case 'D':
+		key = K_LEFT
+	case 'H':
+		key = K_HOME
+	case 'F':
+		key = K_END
 	default:
-		return 0
+		return Event{}
 	}
 
-	panic("unreachable")
+	return Event{Type: EventKey, Key: key, Mod: mod}
 }
 
-func parseKey(buf []byte) int32 {
-	if len(buf) < 1 {
-		return 0
+// parseTilde decodes a "CSI n~" or "CSI n;<mod>~" sequence identifying a
+// function or navigation key.
+func parseTilde(seq []byte) Event {
+	var (
+		parts = strings.SplitN(string(seq), ";", 2)
+		mod   Modifier
+	)
+	if len(parts) > 1 {
+		mod = modifierFromParam(parts[1])
 	}
 
-	switch buf[0] {
-	case '\033':
-		return parseESC(buf[1:])
+	var key int32
+	switch parts[0] {
+	case "2":
+		key = K_INSERT
+	case "3":
+		key = K_DELETE
+	case "5":
+		key = K_PAGEUP
+	case "6":
+		key = K_PAGEDOWN
 	default:
-		return int32(buf[0])
+		return Event{}
 	}
 
-	panic("unreachable")
+	return Event{Type: EventKey, Key: key, Mod: mod}
 }
 
+// parseSGRMouse decodes the parameters of an SGR mouse report
+// ("CSI <b;x;yM" for a press/drag/wheel event, "...m" for a release), seq
+// being the "b;x;y" portion and final the trailing M or m.
+func parseSGRMouse(seq []byte, final byte) Event {
+	var parts = strings.SplitN(string(seq), ";", 3)
+	if len(parts) != 3 {
+		return Event{}
+	}
+
+	b, _ := strconv.Atoi(parts[0])
+	x, _ := strconv.Atoi(parts[1])
+	y, _ := strconv.Atoi(parts[2])
+
+	btn, moving := mouseButton(b, final == 'm')
+	return Event{
+		Type:        EventMouse,
+		MouseX:      x - 1,
+		MouseY:      y - 1,
+		MouseButton: btn,
+		Moving:      moving,
+		Mod:         mouseModifier(b),
+	}
+}
+
+// parseX10Mouse decodes the three raw bytes following "CSI M" in a legacy
+// X10 mouse report: button-and-modifier, column+32, row+32.
+func parseX10Mouse(b []byte) Event {
+	var (
+		btn = int(b[0]) - 32
+		x   = int(b[1]) - 32 - 1
+		y   = int(b[2]) - 32 - 1
+	)
+
+	mbtn, moving := mouseButton(btn, false)
+	return Event{
+		Type:        EventMouse,
+		MouseX:      x,
+		MouseY:      y,
+		MouseButton: mbtn,
+		Moving:      moving,
+		Mod:         mouseModifier(btn),
+	}
+}
+
+// mouseButton interprets the button-and-modifier field common to SGR and
+// X10 mouse reports. release is forced for SGR reports terminated by 'm'.
+// The second return value reports whether bit 0x20 (motion) was set,
+// meaning the button is being dragged rather than freshly pressed or
+// released.
+func mouseButton(b int, release bool) (MouseButton, bool) {
+	moving := b&32 != 0
+
+	if b&64 != 0 {
+		if b&1 != 0 {
+			return MouseWheelDown, moving
+		}
+		return MouseWheelUp, moving
+	}
+	if release {
+		return MouseRelease, moving
+	}
+
+	switch b & 3 {
+	case 0:
+		return MouseLeft, moving
+	case 1:
+		return MouseMiddle, moving
+	case 2:
+		return MouseRight, moving
+	default:
+		return MouseRelease, moving
+	}
+}
+
+// mouseModifier extracts Shift/Alt/Ctrl from the button-and-modifier field
+// common to SGR and X10 mouse reports.
+func mouseModifier(b int) Modifier {
+	var mod Modifier
+	if b&4 != 0 {
+		mod |= ModShift
+	}
+	if b&8 != 0 {
+		mod |= ModAlt
+	}
+	if b&16 != 0 {
+		mod |= ModCtrl
+	}
+	return mod
+}
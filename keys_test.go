@@ -0,0 +1,129 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import "testing"
+
+// TestParseEventKeys exercises parseEvent's handling of plain bytes: control
+// characters, backspace, and a multi-byte UTF-8 rune.
+func TestParseEventKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		n    int
+		want Event
+	}{
+		{"tab", []byte{K_TAB}, 1, Event{Type: EventKey, Key: K_TAB}},
+		{"enter", []byte{K_ENTER}, 1, Event{Type: EventKey, Key: K_ENTER}},
+		{"ctrl-a", []byte{1}, 1, Event{Type: EventKey, Ch: 'a', Mod: ModCtrl}},
+		{"ctrl-z", []byte{26}, 1, Event{Type: EventKey, Ch: 'z', Mod: ModCtrl}},
+		{"backspace", []byte{K_BACKSPACE}, 1, Event{Type: EventKey, Key: K_BACKSPACE}},
+		{"ascii", []byte("x"), 1, Event{Type: EventKey, Ch: 'x'}},
+		{"utf8", []byte("é"), 2, Event{Type: EventKey, Ch: 'é'}},
+	}
+
+	for _, tt := range tests {
+		n, ev := parseEvent(tt.in)
+		if n != tt.n || ev != tt.want {
+			t.Errorf("%s: parseEvent(%v) = %d, %+v, want %d, %+v", tt.name, tt.in, n, ev, tt.n, tt.want)
+		}
+	}
+}
+
+// TestParseEventIncomplete checks that parseEvent reports 0 bytes consumed
+// when buf is a prefix of a longer UTF-8 rune, so the caller waits for more
+// input instead of misparsing a partial sequence.
+func TestParseEventIncomplete(t *testing.T) {
+	if n, _ := parseEvent([]byte("é")[:1]); n != 0 {
+		t.Errorf("parseEvent(partial utf8) = %d, want 0", n)
+	}
+}
+
+// TestParseCSICursorKeys covers plain and Shift/Alt/Ctrl-modified arrow keys.
+func TestParseCSICursorKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want Event
+	}{
+		{"\x1b[A", 3, Event{Type: EventKey, Key: K_UP}},
+		{"\x1b[B", 3, Event{Type: EventKey, Key: K_DOWN}},
+		{"\x1b[1;2C", 6, Event{Type: EventKey, Key: K_RIGHT, Mod: ModShift}},
+		{"\x1b[1;5D", 6, Event{Type: EventKey, Key: K_LEFT, Mod: ModCtrl}},
+		{"\x1b[1;4H", 6, Event{Type: EventKey, Key: K_HOME, Mod: ModShift | ModAlt}},
+	}
+
+	for _, tt := range tests {
+		n, ev := parseCSI([]byte(tt.in))
+		if n != tt.n || ev != tt.want {
+			t.Errorf("parseCSI(%q) = %d, %+v, want %d, %+v", tt.in, n, ev, tt.n, tt.want)
+		}
+	}
+}
+
+// TestParseCSITilde covers the "CSI n~" navigation keys.
+func TestParseCSITilde(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Event
+	}{
+		{"\x1b[2~", Event{Type: EventKey, Key: K_INSERT}},
+		{"\x1b[3~", Event{Type: EventKey, Key: K_DELETE}},
+		{"\x1b[5~", Event{Type: EventKey, Key: K_PAGEUP}},
+		{"\x1b[6;5~", Event{Type: EventKey, Key: K_PAGEDOWN, Mod: ModCtrl}},
+	}
+
+	for _, tt := range tests {
+		_, ev := parseCSI([]byte(tt.in))
+		if ev != tt.want {
+			t.Errorf("parseCSI(%q) = %+v, want %+v", tt.in, ev, tt.want)
+		}
+	}
+}
+
+// TestParseSGRMouse covers press, release, wheel and drag reports, checking
+// in particular that a dragged press (motion bit 0x20 set) is reported with
+// Moving true while an otherwise identical fresh press is not.
+func TestParseSGRMouse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Event
+	}{
+		{"left press", "\x1b[<0;5;10M", Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseLeft}},
+		{"left release", "\x1b[<0;5;10m", Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseRelease}},
+		{"left drag", "\x1b[<32;5;10M", Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseLeft, Moving: true}},
+		{"wheel up", "\x1b[<64;5;10M", Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseWheelUp}},
+		{"wheel down", "\x1b[<65;5;10M", Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseWheelDown}},
+		{"shift-ctrl modifier", "\x1b[<22;5;10M", Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseRight, Mod: ModShift | ModCtrl}},
+	}
+
+	for _, tt := range tests {
+		_, ev := parseCSI([]byte(tt.in))
+		if ev != tt.want {
+			t.Errorf("%s: parseCSI(%q) = %+v, want %+v", tt.name, tt.in, ev, tt.want)
+		}
+	}
+}
+
+// TestParseX10Mouse covers the legacy "CSI M" mouse report, including a
+// dragged report from button-event tracking mode.
+func TestParseX10Mouse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want Event
+	}{
+		{"left press", []byte{'\x1b', '[', 'M', 32, 37, 42}, Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseLeft}},
+		{"left drag", []byte{'\x1b', '[', 'M', 32 + 32, 37, 42}, Event{Type: EventMouse, MouseX: 4, MouseY: 9, MouseButton: MouseLeft, Moving: true}},
+	}
+
+	for _, tt := range tests {
+		n, ev := parseCSI(tt.in)
+		if n != 6 || ev != tt.want {
+			t.Errorf("%s: parseCSI(%v) = %d, %+v, want 6, %+v", tt.name, tt.in, n, ev, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package console
+
+// The native* functions and readLoopNative back Console's drawing and
+// input methods when nativeMode is set; see native_windows.go. nativeMode
+// is only ever set by termios_windows.go, so on every other platform these
+// are unreachable - they exist purely so console.go and event.go's
+// nativeMode branches compile here too.
+func nativeClear()                  { panic("console: nativeMode is unsupported outside Windows") }
+func nativeMoveTo(line, column int) { panic("console: nativeMode is unsupported outside Windows") }
+func nativeMoveUp(i int)            { panic("console: nativeMode is unsupported outside Windows") }
+func nativeMoveDown(i int)          { panic("console: nativeMode is unsupported outside Windows") }
+func nativeMoveRight(i int)         { panic("console: nativeMode is unsupported outside Windows") }
+func nativeMoveLeft(i int)          { panic("console: nativeMode is unsupported outside Windows") }
+func nativeSetColor(col Color)      { panic("console: nativeMode is unsupported outside Windows") }
+func nativeWriteString(s string)    { panic("console: nativeMode is unsupported outside Windows") }
+func nativeSetCursorVisible(v bool) { panic("console: nativeMode is unsupported outside Windows") }
+func nativeSetMouseInput(on bool)   { panic("console: nativeMode is unsupported outside Windows") }
+
+func (c *Console) readLoopNative() { panic("console: nativeMode is unsupported outside Windows") }
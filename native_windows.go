@@ -0,0 +1,460 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package console
+
+import (
+	"os"
+	"unicode/utf16"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// This file backs the package's Console primitives on consoles that
+// rejected ENABLE_VIRTUAL_TERMINAL_PROCESSING/_INPUT in termios_windows.go's
+// (*termios).set, driving the legacy Win32 console buffer and input APIs
+// directly instead of writing and parsing escape sequences. golang.org/x/sys
+// /windows doesn't wrap the console buffer calls, so their procedures are
+// resolved by hand, the same way the generated wrappers in that package do.
+var (
+	modkernel32                     = windows.NewLazySystemDLL("kernel32.dll")
+	procFillConsoleOutputCharacterW = modkernel32.NewProc("FillConsoleOutputCharacterW")
+	procFillConsoleOutputAttribute  = modkernel32.NewProc("FillConsoleOutputAttribute")
+	procSetConsoleTextAttribute     = modkernel32.NewProc("SetConsoleTextAttribute")
+	procSetConsoleCursorInfo        = modkernel32.NewProc("SetConsoleCursorInfo")
+	procWriteConsoleOutputW         = modkernel32.NewProc("WriteConsoleOutputW")
+	procReadConsoleInputW           = modkernel32.NewProc("ReadConsoleInputW")
+	procGetConsoleCursorInfo        = modkernel32.NewProc("GetConsoleCursorInfo")
+)
+
+// charInfo mirrors the Win32 CHAR_INFO structure: a single cell as written
+// by WriteConsoleOutputW, a UTF-16 code unit paired with the console
+// attribute to draw it with.
+type charInfo struct {
+	unicodeChar uint16
+	attributes  uint16
+}
+
+// consoleCursorInfo mirrors CONSOLE_CURSOR_INFO, as read and written by
+// {Get,Set}ConsoleCursorInfo.
+type consoleCursorInfo struct {
+	size    uint32
+	visible int32
+}
+
+// The low nibble of a console text attribute selects the foreground
+// colour; the next nibble, shifted left 4, selects the background. Each
+// nibble is itself a bitmask of blue/green/red plus an intensity bit,
+// mirroring the FOREGROUND_*/BACKGROUND_* constants from wincon.h (not
+// exposed by golang.org/x/sys/windows).
+const (
+	foregroundBlue      = 0x0001
+	foregroundGreen     = 0x0002
+	foregroundRed       = 0x0004
+	foregroundIntensity = 0x0008
+)
+
+// ansiAttrBits maps the 8 basic ANSI colours (BLACK..WHITE) to the
+// corresponding foreground attribute bits; shifted left 4, the same table
+// gives the background bits.
+var ansiAttrBits = [8]uint16{
+	0,
+	foregroundRed,
+	foregroundGreen,
+	foregroundRed | foregroundGreen,
+	foregroundBlue,
+	foregroundRed | foregroundBlue,
+	foregroundGreen | foregroundBlue,
+	foregroundRed | foregroundGreen | foregroundBlue,
+}
+
+// nativeCursor is the cell nativeWriteString next writes to; nativeMoveTo
+// sets it. Unlike the VT escape sequences, the Win32 buffer-write calls
+// below address cells directly rather than tracking an implicit cursor, so
+// the package keeps one of its own.
+var nativeCursor windows.Coord
+
+// nativeAttr is the console attribute nativeSetColor last selected; every
+// subsequent nativeWriteString draws with it.
+var nativeAttr = ansiAttrBits[WHITE]
+
+func stdoutHandle() windows.Handle { return windows.Handle(os.Stdout.Fd()) }
+func stdinHandle() windows.Handle  { return windows.Handle(os.Stdin.Fd()) }
+
+// coordArg packs a Coord into the uint32 SetConsoleCursorPosition and the
+// other console calls below expect a COORD argument as, mirroring how
+// golang.org/x/sys/windows.SetConsoleCursorPosition itself packs one.
+func coordArg(c windows.Coord) uintptr {
+	return uintptr(*(*uint32)(unsafe.Pointer(&c)))
+}
+
+// nativeClear fills the whole console screen buffer with blanks in the
+// current attribute and homes the cursor.
+func nativeClear() {
+	h := stdoutHandle()
+
+	var info windows.ConsoleScreenBufferInfo
+	if windows.GetConsoleScreenBufferInfo(h, &info) != nil {
+		return
+	}
+
+	var (
+		cells  = uintptr(info.Size.X) * uintptr(info.Size.Y)
+		origin = windows.Coord{}
+		n      uint32
+	)
+	procFillConsoleOutputCharacterW.Call(uintptr(h), uintptr(' '), cells, coordArg(origin), uintptr(unsafe.Pointer(&n)))
+	procFillConsoleOutputAttribute.Call(uintptr(h), uintptr(nativeAttr), cells, coordArg(origin), uintptr(unsafe.Pointer(&n)))
+
+	windows.SetConsoleCursorPosition(h, origin)
+	nativeCursor = origin
+}
+
+// nativeMoveTo positions the console cursor and updates nativeCursor, the
+// position nativeWriteString next writes from.
+func nativeMoveTo(line, column int) {
+	nativeCursor = windows.Coord{X: int16(column - 1), Y: int16(line - 1)}
+	windows.SetConsoleCursorPosition(stdoutHandle(), nativeCursor)
+}
+
+// nativeMoveUp, nativeMoveDown, nativeMoveRight and nativeMoveLeft back
+// Console's relative cursor movement methods, moving by i cells from the
+// console's actual current cursor position rather than nativeCursor, which
+// starts at the origin wherever the console's cursor is at startup. The
+// console cursor is moved only by MoveTo, Clear and these calls, not by
+// nativeWriteString.
+func nativeMoveUp(i int)    { nativeMoveBy(0, -i) }
+func nativeMoveDown(i int)  { nativeMoveBy(0, i) }
+func nativeMoveRight(i int) { nativeMoveBy(i, 0) }
+func nativeMoveLeft(i int)  { nativeMoveBy(-i, 0) }
+
+func nativeMoveBy(dx, dy int) {
+	h := stdoutHandle()
+
+	var info windows.ConsoleScreenBufferInfo
+	if windows.GetConsoleScreenBufferInfo(h, &info) != nil {
+		return
+	}
+
+	nativeCursor = windows.Coord{
+		X: info.CursorPosition.X + int16(dx),
+		Y: info.CursorPosition.Y + int16(dy),
+	}
+	windows.SetConsoleCursorPosition(h, nativeCursor)
+}
+
+// nativeSetColor selects the console text attribute that approximates col,
+// mapping its ColorValues down to the 8 basic ANSI colours regardless of
+// the Console's OutputMode: nativeMode only applies to consoles that
+// predate 256-colour and truecolour support in the first place.
+func nativeSetColor(col Color) {
+	nativeAttr = nativeColorBits(col.Fore, true) | nativeColorBits(col.Back, false)
+	if col.Attr == BRIGHT {
+		nativeAttr |= foregroundIntensity
+	}
+	procSetConsoleTextAttribute.Call(uintptr(stdoutHandle()), uintptr(nativeAttr))
+}
+
+// nativeColorBits returns the foreground (or, if !isFg, background)
+// attribute bits for v, approximating Color256 and ColorRGB values by
+// their nearest basic ANSI colour.
+func nativeColorBits(v ColorValue, isFg bool) uint16 {
+	var ansi ColorANSI
+	switch t := v.(type) {
+	case ColorANSI:
+		ansi = t
+	case Color256:
+		ansi = nearestANSI(rgbFromColor256(t))
+	case ColorRGB:
+		ansi = nearestANSI(t)
+	default:
+		ansi = WHITE
+		if !isFg {
+			return 0
+		}
+	}
+
+	bits := ansiAttrBits[ansi]
+	if !isFg {
+		bits <<= 4
+	}
+	return bits
+}
+
+// nativeWriteString draws s at nativeCursor in the console's current
+// attribute via WriteConsoleOutputW, then advances nativeCursor past it.
+// Runes outside the Basic Multilingual Plane are written as the UTF-16
+// surrogate pair the console buffer itself stores them as.
+func nativeWriteString(s string) {
+	units := utf16.Encode([]rune(s))
+	if len(units) == 0 {
+		return
+	}
+
+	cells := make([]charInfo, len(units))
+	for i, u := range units {
+		cells[i] = charInfo{unicodeChar: u, attributes: nativeAttr}
+	}
+
+	var (
+		bufSize  = windows.Coord{X: int16(len(cells)), Y: 1}
+		bufCoord = windows.Coord{}
+		region   = windows.SmallRect{
+			Left: nativeCursor.X, Top: nativeCursor.Y,
+			Right: nativeCursor.X + int16(len(cells)) - 1, Bottom: nativeCursor.Y,
+		}
+	)
+	procWriteConsoleOutputW.Call(
+		uintptr(stdoutHandle()),
+		uintptr(unsafe.Pointer(&cells[0])),
+		coordArg(bufSize),
+		coordArg(bufCoord),
+		uintptr(unsafe.Pointer(&region)),
+	)
+
+	nativeCursor.X += int16(len(cells))
+}
+
+// nativeSetCursorVisible shows or hides the console cursor via
+// SetConsoleCursorInfo, preserving whatever size a prior call to it (or the
+// console's startup default) left it at.
+func nativeSetCursorVisible(visible bool) {
+	h := stdoutHandle()
+
+	info := consoleCursorInfo{size: 25}
+	var cur consoleCursorInfo
+	if r1, _, _ := procGetConsoleCursorInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&cur))); r1 != 0 {
+		info.size = cur.size
+	}
+
+	if visible {
+		info.visible = 1
+	}
+	procSetConsoleCursorInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&info)))
+}
+
+// nativeSetMouseInput toggles the ENABLE_MOUSE_INPUT console mode bit,
+// which governs whether ReadConsoleInputW delivers MOUSE_EVENT_RECORDs at
+// all.
+func nativeSetMouseInput(on bool) {
+	h := stdinHandle()
+
+	var mode uint32
+	if windows.GetConsoleMode(h, &mode) != nil {
+		return
+	}
+	if on {
+		mode |= windows.ENABLE_MOUSE_INPUT
+	} else {
+		mode &^= windows.ENABLE_MOUSE_INPUT
+	}
+	windows.SetConsoleMode(h, mode)
+}
+
+// Win32 INPUT_RECORD event types (wEventType) that readLoopNative handles.
+const (
+	recKeyEvent              = 0x0001
+	recMouseEvent            = 0x0002
+	recWindowBufferSizeEvent = 0x0004
+)
+
+// inputRecord mirrors the Win32 INPUT_RECORD structure: a 16-bit event type
+// tag followed by a union, here left as an opaque 16-byte blob and
+// reinterpreted by translateInputRecord according to the tag. 16 bytes is
+// the size of the union's largest member on both 32- and 64-bit Windows.
+type inputRecord struct {
+	eventType uint16
+	_         uint16
+	event     [16]byte
+}
+
+// keyEventRecord mirrors KEY_EVENT_RECORD.
+type keyEventRecord struct {
+	keyDown         int32
+	repeatCount     uint16
+	virtualKeyCode  uint16
+	virtualScanCode uint16
+	unicodeChar     uint16
+	controlKeyState uint32
+}
+
+// mouseEventRecord mirrors MOUSE_EVENT_RECORD.
+type mouseEventRecord struct {
+	position        windows.Coord
+	buttonState     uint32
+	controlKeyState uint32
+	eventFlags      uint32
+}
+
+// Bits of MOUSE_EVENT_RECORD.dwButtonState and dwEventFlags that
+// translateInputRecord inspects.
+const (
+	fromLeft1stButtonPressed = 0x0001
+	rightmostButtonPressed   = 0x0002
+	fromLeft2ndButtonPressed = 0x0004
+	mouseMoved               = 0x0001
+	mouseWheeled             = 0x0004
+)
+
+// Bits of KEY_EVENT_RECORD/MOUSE_EVENT_RECORD.dwControlKeyState that
+// controlKeyModifier inspects.
+const (
+	rightAltPressed  = 0x0001
+	leftAltPressed   = 0x0002
+	rightCtrlPressed = 0x0004
+	leftCtrlPressed  = 0x0008
+	shiftPressed     = 0x0010
+)
+
+// readLoopNative decodes Events from Win32 INPUT_RECORDs read via
+// ReadConsoleInputW and sends them to c.events, the nativeMode counterpart
+// to readLoop's escape-sequence parsing.
+func (c *Console) readLoopNative() {
+	h := stdinHandle()
+
+	for {
+		var (
+			rec inputRecord
+			n   uint32
+		)
+		r1, _, err := procReadConsoleInputW.Call(
+			uintptr(h),
+			uintptr(unsafe.Pointer(&rec)),
+			1,
+			uintptr(unsafe.Pointer(&n)),
+		)
+		if r1 == 0 {
+			c.events <- Event{Type: EventError, Err: err}
+			return
+		}
+
+		if ev, ok := translateInputRecord(rec); ok {
+			c.events <- ev
+		}
+	}
+}
+
+// translateInputRecord decodes rec into the Event it describes. It reports
+// false for records readLoopNative should drop: key-up, and resize (the
+// poll-based watchResize, shared with the VT path, already covers that).
+func translateInputRecord(rec inputRecord) (Event, bool) {
+	switch rec.eventType {
+	case recKeyEvent:
+		k := *(*keyEventRecord)(unsafe.Pointer(&rec.event[0]))
+		if k.keyDown == 0 {
+			return Event{}, false
+		}
+
+		ev := Event{Type: EventKey, Mod: controlKeyModifier(k.controlKeyState)}
+		switch {
+		case virtualKeyToKey(k.virtualKeyCode) != 0:
+			ev.Key = virtualKeyToKey(k.virtualKeyCode)
+		case k.unicodeChar != 0:
+			ev.Ch = rune(k.unicodeChar)
+		default:
+			return Event{}, false
+		}
+		return ev, true
+
+	case recMouseEvent:
+		m := *(*mouseEventRecord)(unsafe.Pointer(&rec.event[0]))
+		btn, moving := nativeMouseButton(m.buttonState, m.eventFlags)
+		return Event{
+			Type:        EventMouse,
+			MouseX:      int(m.position.X),
+			MouseY:      int(m.position.Y),
+			MouseButton: btn,
+			Moving:      moving,
+			Mod:         controlKeyModifier(m.controlKeyState),
+		}, true
+
+	default:
+		return Event{}, false
+	}
+}
+
+// virtualKeyToKey maps the virtual-key codes of the non-printable keys the
+// Unix parser in keys.go also recognises to the same K_* constants; it
+// returns 0 for codes that should instead be taken from the event's
+// UnicodeChar.
+func virtualKeyToKey(vk uint16) int32 {
+	switch vk {
+	case 0x08:
+		return K_BACKSPACE
+	case 0x09:
+		return K_TAB
+	case 0x0D:
+		return K_ENTER
+	case 0x1B:
+		return K_ESCAPE
+	case 0x21:
+		return K_PAGEUP
+	case 0x22:
+		return K_PAGEDOWN
+	case 0x23:
+		return K_END
+	case 0x24:
+		return K_HOME
+	case 0x25:
+		return K_LEFT
+	case 0x26:
+		return K_UP
+	case 0x27:
+		return K_RIGHT
+	case 0x28:
+		return K_DOWN
+	case 0x2D:
+		return K_INSERT
+	case 0x2E:
+		return K_DELETE
+	default:
+		return 0
+	}
+}
+
+// nativeMouseButton interprets a MOUSE_EVENT_RECORD's button state and
+// event flags the way mouseButton interprets an SGR/X10 report's
+// button-and-modifier field. The second return value reports MOUSE_MOVED,
+// meaning a button is being dragged rather than freshly pressed or
+// released.
+func nativeMouseButton(buttonState, eventFlags uint32) (MouseButton, bool) {
+	moving := eventFlags&mouseMoved != 0
+
+	if eventFlags&mouseWheeled != 0 {
+		if int32(buttonState) < 0 {
+			return MouseWheelDown, moving
+		}
+		return MouseWheelUp, moving
+	}
+
+	switch {
+	case buttonState&fromLeft1stButtonPressed != 0:
+		return MouseLeft, moving
+	case buttonState&rightmostButtonPressed != 0:
+		return MouseRight, moving
+	case buttonState&fromLeft2ndButtonPressed != 0:
+		return MouseMiddle, moving
+	default:
+		return MouseRelease, moving
+	}
+}
+
+// controlKeyModifier extracts Shift/Alt/Ctrl from a KEY_EVENT_RECORD or
+// MOUSE_EVENT_RECORD's dwControlKeyState.
+func controlKeyModifier(state uint32) Modifier {
+	var mod Modifier
+	if state&shiftPressed != 0 {
+		mod |= ModShift
+	}
+	if state&(leftAltPressed|rightAltPressed) != 0 {
+		mod |= ModAlt
+	}
+	if state&(leftCtrlPressed|rightCtrlPressed) != 0 {
+		mod |= ModCtrl
+	}
+	return mod
+}
@@ -0,0 +1,164 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import (
+	"os"
+	"strings"
+)
+
+// OutputMode selects how rich a colour a Console is allowed to emit. Colors
+// requested beyond what the mode allows are mapped down to the nearest
+// colour the mode supports.
+type OutputMode uint8
+
+const (
+	// OutputNormal allows only the 8 basic ANSI colours.
+	OutputNormal OutputMode = iota
+	// Output256 allows the terminal's 256-colour palette.
+	Output256
+	// OutputTruecolor allows 24-bit RGB colours.
+	OutputTruecolor
+	// OutputGrayscale maps every colour to a shade of grey.
+	OutputGrayscale
+)
+
+// DetectOutputMode guesses the richest OutputMode the terminal named by
+// $TERM advertises, consulting $COLORTERM for truecolour support.
+func DetectOutputMode() OutputMode {
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return OutputTruecolor
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return Output256
+	}
+	return OutputNormal
+}
+
+// SetOutputMode sets the OutputMode that SetColor clamps colours to.
+func (c *Console) SetOutputMode(mode OutputMode) {
+	c.mode = mode
+}
+
+// OutputMode returns the Console's current OutputMode.
+func (c *Console) OutputMode() OutputMode {
+	return c.mode
+}
+
+// Clamp returns col with its Fore and Back colours mapped down to the
+// richest representation c's OutputMode allows. Callers that build escape
+// sequences from a Color themselves, rather than through SetColor - the
+// frame package's differential Renderer, for instance - should run colours
+// through Clamp before formatting them, so richer colours don't reach a
+// terminal that only advertised a plainer OutputMode.
+func (c *Console) Clamp(col Color) Color {
+	return Color{
+		Attr: col.Attr,
+		Fore: c.clampColor(col.Fore),
+		Back: c.clampColor(col.Back),
+	}
+}
+
+// clampColor maps v down to the richest colour representation c's
+// OutputMode allows.
+func (c *Console) clampColor(v ColorValue) ColorValue {
+	switch c.mode {
+	case OutputTruecolor:
+		return v
+	case Output256:
+		if rgb, ok := v.(ColorRGB); ok {
+			return nearest256(rgb)
+		}
+		return v
+	case OutputGrayscale:
+		return toGrayscale(v)
+	default:
+		switch t := v.(type) {
+		case ColorRGB:
+			return nearestANSI(t)
+		case Color256:
+			return nearestANSI(rgbFromColor256(t))
+		}
+		return v
+	}
+}
+
+// ansiRGB approximates the 8 basic ANSI colours in RGB, in BLACK..WHITE
+// order, for use when downgrading a richer colour.
+var ansiRGB = [8]ColorRGB{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+}
+
+// nearestANSI maps an RGB colour to the closest of the 8 basic ANSI
+// colours, by squared Euclidean distance.
+func nearestANSI(c ColorRGB) ColorANSI {
+	var best ColorANSI
+	var bestDist = 1 << 30
+
+	for i, rgb := range ansiRGB {
+		var (
+			dr = int(c.R) - int(rgb.R)
+			dg = int(c.G) - int(rgb.G)
+			db = int(c.B) - int(rgb.B)
+			d  = dr*dr + dg*dg + db*db
+		)
+		if d < bestDist {
+			best, bestDist = ColorANSI(i), d
+		}
+	}
+
+	return best
+}
+
+// rgbFromColor256 approximates the RGB value of a 256-colour palette index:
+// the first 16 entries are taken to be the basic and bright ANSI colours,
+// 16-231 the 6x6x6 colour cube, and 232-255 the grayscale ramp.
+func rgbFromColor256(v Color256) ColorRGB {
+	n := int(v)
+
+	switch {
+	case n < 16:
+		return ansiRGB[n%8]
+	case n >= 232:
+		level := uint8(8 + (n-232)*10)
+		return ColorRGB{level, level, level}
+	default:
+		n -= 16
+		return ColorRGB{cubeSteps[(n/36)%6], cubeSteps[(n/6)%6], cubeSteps[n%6]}
+	}
+}
+
+// toGrayscale converts v to a shade in the 256-colour palette's 24-step
+// grayscale ramp (indices 232-255) by scaling its perceived luminance
+// linearly from 8..255 onto the ramp, rounding down; the result is not
+// always the ramp entry nearest that luminance.
+func toGrayscale(v ColorValue) ColorValue {
+	var rgb ColorRGB
+	switch t := v.(type) {
+	case ColorRGB:
+		rgb = t
+	case Color256:
+		rgb = rgbFromColor256(t)
+	case ColorANSI:
+		rgb = ansiRGB[int(t)%8]
+	default:
+		return v
+	}
+
+	var (
+		lum = (int(rgb.R)*30 + int(rgb.G)*59 + int(rgb.B)*11) / 100
+		idx = 232 + (lum-8)*23/247
+	)
+	switch {
+	case idx < 232:
+		idx = 232
+	case idx > 255:
+		idx = 255
+	}
+
+	return Color256(idx)
+}
@@ -0,0 +1,68 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package console
+
+import "testing"
+
+// TestToGrayscale checks that toGrayscale maps black, white and a basic
+// ANSI colour to indices within the 256-colour palette's grayscale ramp
+// (232-255), and leaves values it doesn't recognise untouched.
+func TestToGrayscale(t *testing.T) {
+	tests := []struct {
+		name string
+		in   ColorValue
+		want ColorValue
+	}{
+		{"black rgb", ColorRGB{0, 0, 0}, Color256(232)},
+		{"white rgb", ColorRGB{255, 255, 255}, Color256(255)},
+		{"white ansi", ColorANSI(WHITE), Color256(252)},
+		{"default passthrough", ColorDefault{}, ColorDefault{}},
+	}
+
+	for _, tt := range tests {
+		if got := toGrayscale(tt.in); got != tt.want {
+			t.Errorf("%s: toGrayscale(%+v) = %+v, want %+v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestNearestANSI checks that nearestANSI maps the exact ansiRGB table
+// entries back to their own index, and an in-between colour to its closer
+// neighbour.
+func TestNearestANSI(t *testing.T) {
+	for i, rgb := range ansiRGB {
+		if got := nearestANSI(rgb); got != ColorANSI(i) {
+			t.Errorf("nearestANSI(%+v) = %d, want %d", rgb, got, i)
+		}
+	}
+
+	if got := nearestANSI(ColorRGB{200, 10, 10}); got != RED {
+		t.Errorf("nearestANSI(near-red) = %d, want %d", got, RED)
+	}
+}
+
+// TestClamp checks that Console.Clamp maps a truecolour value down to the
+// nearest representation each OutputMode allows.
+func TestClamp(t *testing.T) {
+	var c = New(nil, nil)
+	rgb := ColorRGB{255, 0, 0}
+
+	tests := []struct {
+		mode OutputMode
+		want ColorValue
+	}{
+		{OutputTruecolor, rgb},
+		{Output256, nearest256(rgb)},
+		{OutputNormal, nearestANSI(rgb)},
+		{OutputGrayscale, toGrayscale(rgb)},
+	}
+
+	for _, tt := range tests {
+		c.SetOutputMode(tt.mode)
+		if got := c.Clamp(Color{Fore: rgb}); got.Fore != tt.want {
+			t.Errorf("mode %d: Clamp(%+v).Fore = %+v, want %+v", tt.mode, rgb, got.Fore, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package console
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchResize sends an EventResize whenever the controlling terminal
+// delivers SIGWINCH.
+func (c *Console) watchResize() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+
+	for range ch {
+		if w, h, err := windowSize(0); err == nil {
+			c.events <- Event{Type: EventResize, Width: w, Height: h}
+		}
+	}
+}
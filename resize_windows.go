@@ -0,0 +1,33 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package console
+
+import "time"
+
+// resizePollInterval is how often watchResize checks the console window
+// size. Windows has no SIGWINCH equivalent, so resizes are detected by
+// polling rather than by a signal.
+const resizePollInterval = 250 * time.Millisecond
+
+// watchResize sends an EventResize whenever the size of the console window
+// attached to stdout changes.
+func (c *Console) watchResize() {
+	w, h, err := windowSize(0)
+	if err != nil {
+		return
+	}
+
+	for range time.Tick(resizePollInterval) {
+		nw, nh, err := windowSize(0)
+		if err != nil || (nw == w && nh == h) {
+			continue
+		}
+
+		w, h = nw, nh
+		c.events <- Event{Type: EventResize, Width: w, Height: h}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminfo
+
+// vt100 is shared by the fallback entries below: it is the set of
+// capability strings understood by essentially every VT100-descended
+// terminal, including xterm, screen and the Linux console.
+var vt100 = Terminfo{
+	Cup:   "\x1b[%i%p1%d;%p2%dH",
+	Clear: "\x1b[H\x1b[2J",
+	El:    "\x1b[K",
+	Civis: "\x1b[?25l",
+	Cnorm: "\x1b[?25h",
+	Smcup: "\x1b[?1049h",
+	Rmcup: "\x1b[?1049l",
+	Setaf: "\x1b[3%p1%dm",
+	Setab: "\x1b[4%p1%dm",
+	Sgr0:  "\x1b[0m",
+	Cuu:   "\x1b[%p1%dA",
+	Cud:   "\x1b[%p1%dB",
+	Cuf:   "\x1b[%p1%dC",
+	Cub:   "\x1b[%p1%dD",
+	Kcuu1: "\x1b[A",
+	Kcud1: "\x1b[B",
+	Kcuf1: "\x1b[C",
+	Kcub1: "\x1b[D",
+	Khome: "\x1b[1~",
+	Kend:  "\x1b[4~",
+	Kpp:   "\x1b[5~",
+	Knp:   "\x1b[6~",
+	Kich1: "\x1b[2~",
+	Kdch1: "\x1b[3~",
+}
+
+// fallbacks holds compiled-in capability tables for terminals commonly
+// found in the wild, used when no terminfo database entry can be loaded
+// for them.
+var fallbacks = map[string]*Terminfo{
+	"xterm":          &vt100,
+	"xterm-256color": &vt100,
+	"screen":         &vt100,
+	"linux":          &vt100,
+}
+
+// Fallback returns the compiled-in capability table for the terminal named
+// term, or the xterm table if term is not one of the terminals built in.
+func Fallback(term string) *Terminfo {
+	if t, ok := fallbacks[term]; ok {
+		return t
+	}
+	return &vt100
+}
@@ -0,0 +1,215 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminfo
+
+import "strconv"
+
+// Eval expands a parameterized capability string such as Terminfo.Cup or
+// Terminfo.Setaf, substituting params according to the mini-language
+// described in terminfo(5): %p1..%p9 push a parameter, %d/%c pop and format
+// a value, %{n} and %'c' push constants, %i increments the first two
+// parameters, the usual arithmetic, logical and comparison operators
+// combine stack values, and %?cond%tthen%eelse%; selects between two
+// expansions.
+func Eval(s string, params ...int) string {
+	var (
+		out   []byte
+		stack []int
+		vars  [26]int
+	)
+
+	push := func(v int) { stack = append(stack, v) }
+	pop := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	pop2 := func() (a, b int) {
+		b = pop()
+		a = pop()
+		return
+	}
+	boolInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	i := 0
+	for i < len(s) {
+		if s[i] != '%' {
+			out = append(out, s[i])
+			i++
+			continue
+		}
+
+		i++
+		if i >= len(s) {
+			break
+		}
+
+		switch s[i] {
+		case '%':
+			out = append(out, '%')
+		case 'i':
+			if len(params) > 0 {
+				params[0]++
+			}
+			if len(params) > 1 {
+				params[1]++
+			}
+		case 'd':
+			out = append(out, strconv.Itoa(pop())...)
+		case 'c':
+			out = append(out, byte(pop()))
+		case 'p':
+			i++
+			if i < len(s) {
+				n := int(s[i] - '0')
+				if n >= 1 && n <= len(params) {
+					push(params[n-1])
+				} else {
+					push(0)
+				}
+			}
+		case 'P':
+			i++
+			if i < len(s) && s[i] >= 'a' && s[i] <= 'z' {
+				vars[s[i]-'a'] = pop()
+			}
+		case 'g':
+			i++
+			if i < len(s) && s[i] >= 'a' && s[i] <= 'z' {
+				push(vars[s[i]-'a'])
+			}
+		case '\'':
+			if i+1 < len(s) {
+				push(int(s[i+1]))
+				i += 2
+			}
+		case '{':
+			j := i + 1
+			for j < len(s) && s[j] != '}' {
+				j++
+			}
+			n, _ := strconv.Atoi(s[i+1 : j])
+			push(n)
+			i = j
+		case '+':
+			a, b := pop2()
+			push(a + b)
+		case '-':
+			a, b := pop2()
+			push(a - b)
+		case '*':
+			a, b := pop2()
+			push(a * b)
+		case '/':
+			a, b := pop2()
+			if b != 0 {
+				push(a / b)
+			} else {
+				push(0)
+			}
+		case 'm':
+			a, b := pop2()
+			if b != 0 {
+				push(a % b)
+			} else {
+				push(0)
+			}
+		case '&':
+			a, b := pop2()
+			push(a & b)
+		case '|':
+			a, b := pop2()
+			push(a | b)
+		case '^':
+			a, b := pop2()
+			push(a ^ b)
+		case '=':
+			a, b := pop2()
+			push(boolInt(a == b))
+		case '>':
+			a, b := pop2()
+			push(boolInt(a > b))
+		case '<':
+			a, b := pop2()
+			push(boolInt(a < b))
+		case 'A':
+			a, b := pop2()
+			push(boolInt(a != 0 && b != 0))
+		case 'O':
+			a, b := pop2()
+			push(boolInt(a != 0 || b != 0))
+		case '!':
+			push(boolInt(pop() == 0))
+		case '~':
+			push(^pop())
+		case '?':
+			// Start of a conditional; the branches are expanded below once
+			// %t is reached.
+		case 't':
+			var (
+				cond         = pop() != 0
+				thenEnd, end = matchConditional(s, i+1)
+			)
+			if cond {
+				out = append(out, Eval(s[i+1:thenEnd], params...)...)
+			} else if end > thenEnd {
+				out = append(out, Eval(s[thenEnd+2:end], params...)...)
+			}
+			// end is the index of the '%' of the closing "%;"; advance past
+			// both of its bytes so the ';' isn't emitted as literal text by
+			// the loop's trailing i++.
+			i = end + 1
+		}
+		i++
+	}
+
+	return string(out)
+}
+
+// matchConditional scans s, starting just past a %t, for the %e and %;
+// that close the enclosing %? conditional, accounting for nested
+// conditionals. thenEnd is the index of the %e (or of the %; if there is
+// no else branch) and end is the index of the closing %;.
+func matchConditional(s string, start int) (thenEnd, end int) {
+	thenEnd = -1
+	depth := 0
+
+	for i := start; i < len(s)-1; i++ {
+		if s[i] != '%' {
+			continue
+		}
+
+		switch s[i+1] {
+		case '?':
+			depth++
+		case ';':
+			if depth == 0 {
+				if thenEnd == -1 {
+					thenEnd = i
+				}
+				return thenEnd, i
+			}
+			depth--
+		case 'e':
+			if depth == 0 && thenEnd == -1 {
+				thenEnd = i
+			}
+		}
+	}
+
+	if thenEnd == -1 {
+		thenEnd = len(s)
+	}
+
+	return thenEnd, len(s)
+}
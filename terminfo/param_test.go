@@ -0,0 +1,32 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terminfo
+
+import "testing"
+
+// TestEvalConditional exercises the %?...%t...%e...%; conditional using
+// xterm-256color's real setaf capability string, which is representative of
+// the setaf/setab strings shipped by virtually every modern terminfo entry.
+func TestEvalConditional(t *testing.T) {
+	const setaf = "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m"
+
+	tests := []struct {
+		param int
+		want  string
+	}{
+		{0, "\x1b[30m"},
+		{1, "\x1b[31m"},
+		{7, "\x1b[37m"},
+		{9, "\x1b[91m"},
+		{15, "\x1b[97m"},
+		{200, "\x1b[38;5;200m"},
+	}
+
+	for _, tt := range tests {
+		if got := Eval(setaf, tt.param); got != tt.want {
+			t.Errorf("Eval(setaf, %d) = %q, want %q", tt.param, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package terminfo reads the capability strings a terminal advertises
+// through the compiled terminfo database, so that the console package does
+// not need to hardcode escape sequences for a single terminal type.
+package terminfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// magic is the value of the first int16 of a compiled terminfo entry in the
+// classic (non-extended) format, where numeric capabilities are 16-bit.
+const magic = 0x011A
+
+// magicExtended is the value of the first int16 of a compiled terminfo
+// entry in the "extended-number" format, which widens numeric capabilities
+// to 32 bits so they can hold values beyond 32767 (colour counts on
+// feature-rich terminals, for example). It is otherwise laid out exactly
+// like the classic format, and is what terminals such as xterm-256color,
+// screen-256color and tmux-256color ship on modern systems.
+const magicExtended = 0x021E
+
+// Indices of the string capabilities Terminfo reads, within the string
+// table of a compiled terminfo entry. The order is fixed by terminfo(5)
+// and is the same for every terminal's compiled entry.
+const (
+	idxClear = 5
+	idxEl    = 6
+	idxCup   = 10
+	idxCivis = 13
+	idxCnorm = 16
+	idxSmcup = 28
+	idxRmcup = 40
+	idxSgr0  = 39
+	idxKdch1 = 59
+	idxKcud1 = 61
+	idxKhome = 76
+	idxKich1 = 77
+	idxKcub1 = 79
+	idxKnp   = 81
+	idxKpp   = 82
+	idxKcuf1 = 83
+	idxKcuu1 = 87
+	idxCud   = 107
+	idxCub   = 111
+	idxCuf   = 112
+	idxCuu   = 114
+	idxKend  = 164
+	idxSetaf = 359
+	idxSetab = 360
+)
+
+// Terminfo holds the subset of a terminal's capabilities that console
+// needs: cursor motion, clearing, cursor visibility, the alternate screen,
+// colour, and the strings sent by the cursor and navigation keys.
+type Terminfo struct {
+	Cup   string // cursor_address: move the cursor to a row and column
+	Clear string // clear_screen
+	El    string // clr_eol: erase from the cursor to the end of the line
+	Civis string // cursor_invisible
+	Cnorm string // cursor_normal
+	Smcup string // enter_ca_mode: switch to the alternate screen
+	Rmcup string // exit_ca_mode
+	Setaf string // set_a_foreground
+	Setab string // set_a_background
+	Sgr0  string // exit_attribute_mode: reset SGR state
+	Cuu   string // parm_up_cursor
+	Cud   string // parm_down_cursor
+	Cuf   string // parm_right_cursor
+	Cub   string // parm_left_cursor
+
+	Kcuu1 string // key_up
+	Kcud1 string // key_down
+	Kcuf1 string // key_right
+	Kcub1 string // key_left
+	Khome string // key_home
+	Kend  string // key_end
+	Kpp   string // key_ppage
+	Knp   string // key_npage
+	Kich1 string // key_ic
+	Kdch1 string // key_dc
+}
+
+// Parse decodes data as a compiled terminfo entry, the binary format
+// written by tic(1), and returns the capabilities Terminfo understands.
+// The header is 6 little-endian int16s giving the magic number, the size
+// of the terminal names section, the number of boolean, numeric and string
+// capabilities, and the size of the string table; it is followed by the
+// names, the boolean flags, the numbers (aligned to an even offset), a
+// table of int16 offsets into the string table, and the string table
+// itself. Both the classic format (magic, 16-bit numbers) and the
+// extended-number format (magicExtended, 32-bit numbers) are accepted;
+// Terminfo only exposes string capabilities, so the two differ solely in
+// how much space the number section occupies.
+func Parse(data []byte) (*Terminfo, error) {
+	if len(data) < 12 {
+		return nil, errors.New("terminfo: truncated header")
+	}
+
+	var header [6]int16
+	for i := range header {
+		header[i] = int16(binary.LittleEndian.Uint16(data[i*2 : i*2+2]))
+	}
+	var numSize int
+	switch header[0] {
+	case magic:
+		numSize = 2
+	case magicExtended:
+		numSize = 4
+	default:
+		return nil, fmt.Errorf("terminfo: unsupported magic number %#x", uint16(header[0]))
+	}
+
+	var (
+		nameSize    = int(header[1])
+		boolCount   = int(header[2])
+		numCount    = int(header[3])
+		strCount    = int(header[4])
+		strTableLen = int(header[5])
+	)
+
+	pos := 12 + nameSize + boolCount
+	if pos%2 != 0 {
+		pos++
+	}
+	pos += numCount * numSize
+
+	if pos+strCount*2+strTableLen > len(data) {
+		return nil, errors.New("terminfo: truncated string section")
+	}
+
+	var (
+		offsets    = data[pos : pos+strCount*2]
+		tableStart = pos + strCount*2
+		table      = data[tableStart : tableStart+strTableLen]
+	)
+
+	str := func(idx int) string {
+		if idx >= strCount {
+			return ""
+		}
+		off := int16(binary.LittleEndian.Uint16(offsets[idx*2 : idx*2+2]))
+		if off < 0 || int(off) >= len(table) {
+			return ""
+		}
+		end := int(off)
+		for end < len(table) && table[end] != 0 {
+			end++
+		}
+		return string(table[off:end])
+	}
+
+	return &Terminfo{
+		Cup:   str(idxCup),
+		Clear: str(idxClear),
+		El:    str(idxEl),
+		Civis: str(idxCivis),
+		Cnorm: str(idxCnorm),
+		Smcup: str(idxSmcup),
+		Rmcup: str(idxRmcup),
+		Setaf: str(idxSetaf),
+		Setab: str(idxSetab),
+		Sgr0:  str(idxSgr0),
+		Cuu:   str(idxCuu),
+		Cud:   str(idxCud),
+		Cuf:   str(idxCuf),
+		Cub:   str(idxCub),
+		Kcuu1: str(idxKcuu1),
+		Kcud1: str(idxKcud1),
+		Kcuf1: str(idxKcuf1),
+		Kcub1: str(idxKcub1),
+		Khome: str(idxKhome),
+		Kend:  str(idxKend),
+		Kpp:   str(idxKpp),
+		Knp:   str(idxKnp),
+		Kich1: str(idxKich1),
+		Kdch1: str(idxKdch1),
+	}, nil
+}
+
+// searchDirs lists the directories searched for compiled terminfo entries,
+// in the order ncurses itself consults them.
+func searchDirs() []string {
+	var dirs []string
+
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	if list := os.Getenv("TERMINFO_DIRS"); list != "" {
+		dirs = append(dirs, strings.Split(list, ":")...)
+	}
+
+	return append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo")
+}
+
+// Load finds and parses the compiled terminfo entry for the terminal named
+// term, searching the usual terminfo database directories. Entries are
+// stored under a subdirectory named either after the first letter of term
+// or, on some systems, its hex value, so both are tried.
+func Load(term string) (*Terminfo, error) {
+	if term == "" {
+		return nil, errors.New("terminfo: empty terminal name")
+	}
+
+	var subdirs = []string{term[0:1], strconv.FormatInt(int64(term[0]), 16)}
+
+	for _, dir := range searchDirs() {
+		for _, sub := range subdirs {
+			data, err := os.ReadFile(filepath.Join(dir, sub, term))
+			if err != nil {
+				continue
+			}
+			return Parse(data)
+		}
+	}
+
+	return nil, fmt.Errorf("terminfo: no entry found for %q", term)
+}
@@ -2,6 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+//go:build !windows
+
 package console
 
 import (
@@ -63,6 +65,30 @@ func (t *termios) set() error {
 	return nil
 }
 
+// winsize mirrors struct winsize from <sys/ioctl.h>, as filled in by the
+// TIOCGWINSZ ioctl.
+type winsize struct {
+	Row, Col, Xpixel, Ypixel uint16
+}
+
+// windowSize returns the width and height, in character cells, of the
+// terminal attached to file descriptor fd.
+func windowSize(fd uintptr) (width, height int, err error) {
+	var ws winsize
+	r1, _, errno := syscall.Syscall(syscall.SYS_IOCTL,
+		fd, syscall.TIOCGWINSZ,
+		uintptr(unsafe.Pointer(&ws)))
+
+	if errno != 0 {
+		return 0, 0, os.NewSyscallError("SYS_IOCTL", errno)
+	}
+	if r1 != 0 {
+		return 0, 0, errors.New("windowSize: unhandled error")
+	}
+
+	return int(ws.Col), int(ws.Row), nil
+}
+
 func (t *termios) rawMode() {
 	t.Iflag &= ^uint32(syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON)
 	t.Oflag &= ^uint32(syscall.OPOST)
@@ -0,0 +1,97 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package console
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// termios saves the console modes of stdin and stdout, mirroring the role
+// the Unix termios type plays: Exit restores them via set.
+type termios struct {
+	stdinMode, stdoutMode uint32
+}
+
+var defaultTermios *termios
+
+func init() {
+	var err error
+	defaultTermios, err = getTermios()
+	if err != nil {
+		panic(err)
+	}
+}
+
+func getTermios() (*termios, error) {
+	t := new(termios)
+
+	if err := windows.GetConsoleMode(windows.Handle(os.Stdin.Fd()), &t.stdinMode); err != nil {
+		return nil, err
+	}
+	if err := windows.GetConsoleMode(windows.Handle(os.Stdout.Fd()), &t.stdoutMode); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// rawMode disables line buffering, echo and Ctrl+C processing on input,
+// requests virtual terminal sequence processing on input and output, and
+// asks for window and mouse input records so a native readLoopNative has
+// something to read if VT turns out not to be supported. On Windows 10 and
+// later this is enough for the escape sequences the rest of the package
+// writes and parses to work unchanged; on older consoles that don't
+// support virtual terminal sequences, set falls back to nativeMode, in
+// which Console drives the legacy console buffer and input APIs directly
+// (see native_windows.go).
+func (t *termios) rawMode() {
+	t.stdinMode &^= windows.ENABLE_LINE_INPUT | windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT
+	t.stdinMode |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT | windows.ENABLE_WINDOW_INPUT | windows.ENABLE_MOUSE_INPUT
+
+	t.stdoutMode |= windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING | windows.ENABLE_PROCESSED_OUTPUT
+}
+
+// set applies t's modes to stdin and stdout. If the console rejects the
+// virtual terminal flags set by rawMode - the case on consoles that
+// predate Windows 10's VT support - set clears them, switches the package
+// to nativeMode, and retries, rather than failing Init outright.
+func (t *termios) set() error {
+	if err := windows.SetConsoleMode(windows.Handle(os.Stdout.Fd()), t.stdoutMode); err != nil {
+		nativeMode = true
+		t.stdoutMode &^= windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING
+		if err := windows.SetConsoleMode(windows.Handle(os.Stdout.Fd()), t.stdoutMode); err != nil {
+			return err
+		}
+	}
+
+	if err := windows.SetConsoleMode(windows.Handle(os.Stdin.Fd()), t.stdinMode); err != nil {
+		nativeMode = true
+		t.stdinMode &^= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+		if err := windows.SetConsoleMode(windows.Handle(os.Stdin.Fd()), t.stdinMode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// windowSize returns the width and height, in character cells, of the
+// console window attached to stdout. fd is accepted for symmetry with the
+// Unix implementation but is otherwise unused: Windows consoles don't
+// expose their buffer through a plain file descriptor.
+func windowSize(fd uintptr) (width, height int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info); err != nil {
+		return 0, 0, err
+	}
+
+	return int(info.Window.Right-info.Window.Left) + 1,
+		int(info.Window.Bottom-info.Window.Top) + 1,
+		nil
+}
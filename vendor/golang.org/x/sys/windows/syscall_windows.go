@@ -0,0 +1,72 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Windows system calls.
+
+package windows
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type Handle uintptr
+
+// UTF16ToString returns the Go string representation of the UTF-16 sequence
+// s, with a terminating NUL and any bytes after the NUL removed.
+func UTF16ToString(s []uint16) string {
+	return syscall.UTF16ToString(s)
+}
+
+// UTF16FromString returns the UTF-16 encoding of s, with a terminating NUL
+// appended. If s contains a NUL byte at any location, it returns
+// (nil, syscall.EINVAL).
+func UTF16FromString(s string) ([]uint16, error) {
+	return syscall.UTF16FromString(s)
+}
+
+// UTF16PtrFromString returns a pointer to a NUL-terminated UTF-16 sequence
+// containing the text of s. If s contains a NUL byte at any location, it
+// returns (nil, syscall.EINVAL).
+func UTF16PtrFromString(s string) (*uint16, error) {
+	a, err := UTF16FromString(s)
+	if err != nil {
+		return nil, err
+	}
+	return &a[0], nil
+}
+
+// GetProcAddressByOrdinal retrieves the address of the exported function
+// from module by its ordinal, used by DLL.FindProcByOrdinal.
+func GetProcAddressByOrdinal(module Handle, ordinal uintptr) (proc uintptr, err error) {
+	r0, _, e1 := syscall.Syscall(procGetProcAddress.Addr(), 2, uintptr(module), ordinal, 0)
+	proc = uintptr(r0)
+	if proc == 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+// GetSystemDirectory retrieves the path of the Windows System directory,
+// used by loadLibraryEx (dll_windows.go) as a fallback for machines too old
+// to support LOAD_LIBRARY_SEARCH_SYSTEM32.
+func GetSystemDirectory() (string, error) {
+	n := uint32(MAX_PATH)
+	for {
+		b := make([]uint16, n)
+		l, e := getSystemDirectory(&b[0], n)
+		if e != nil {
+			return "", e
+		}
+		if l <= n {
+			return UTF16ToString(b[:l]), nil
+		}
+		n = l
+	}
+}
+
+// SetConsoleCursorPosition moves the cursor of console to position.
+func SetConsoleCursorPosition(console Handle, position Coord) error {
+	return setConsoleCursorPosition(console, *((*uint32)(unsafe.Pointer(&position))))
+}
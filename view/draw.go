@@ -0,0 +1,99 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package view
+
+import (
+	"console"
+	"console/frame"
+	"image"
+)
+
+// composite draws v's border, title and visible scrollback into root at v's
+// bounds.
+func composite(root *frame.Frame, v *View) {
+	root.ClearRect(v.bounds)
+	drawBorder(root, v.bounds, v.Title)
+
+	inner := image.Rect(
+		v.bounds.Min.X+1, v.bounds.Min.Y+1,
+		v.bounds.Max.X-1, v.bounds.Max.Y-1,
+	)
+	if inner.Empty() {
+		return
+	}
+
+	lines := v.lines
+	if v.Wrap {
+		lines = wrapLines(lines, inner.Dx())
+	}
+
+	for y := 0; y < inner.Dy(); y++ {
+		idx := v.originY + y
+		if idx >= len(lines) {
+			break
+		}
+		line := lines[idx]
+
+		startX := 0
+		if !v.Wrap {
+			startX = v.originX
+		}
+
+		for x := 0; x < inner.Dx(); x++ {
+			col := startX + x
+			if col >= len(line) {
+				break
+			}
+			root.Set(inner.Min.X+x, inner.Min.Y+y, line[col])
+		}
+	}
+}
+
+// drawBorder draws a single-line box-drawing border around b, with title
+// set into the top edge.
+func drawBorder(f *frame.Frame, b image.Rectangle, title string) {
+	if b.Dx() < 2 || b.Dy() < 2 {
+		return
+	}
+
+	var col console.Color
+
+	f.Set(b.Min.X, b.Min.Y, frame.Cell{R: '┌', C: col})
+	f.Set(b.Max.X-1, b.Min.Y, frame.Cell{R: '┐', C: col})
+	f.Set(b.Min.X, b.Max.Y-1, frame.Cell{R: '└', C: col})
+	f.Set(b.Max.X-1, b.Max.Y-1, frame.Cell{R: '┘', C: col})
+
+	for x := b.Min.X + 1; x < b.Max.X-1; x++ {
+		f.Set(x, b.Min.Y, frame.Cell{R: '─', C: col})
+		f.Set(x, b.Max.Y-1, frame.Cell{R: '─', C: col})
+	}
+	for y := b.Min.Y + 1; y < b.Max.Y-1; y++ {
+		f.Set(b.Min.X, y, frame.Cell{R: '│', C: col})
+		f.Set(b.Max.X-1, y, frame.Cell{R: '│', C: col})
+	}
+
+	if title != "" {
+		f.PutText(b.Min.X+2, b.Min.Y, title)
+	}
+}
+
+// wrapLines splits each line in lines into chunks of at most width Cells,
+// for Views with Wrap set.
+func wrapLines(lines [][]frame.Cell, width int) [][]frame.Cell {
+	if width <= 0 {
+		return lines
+	}
+
+	var out [][]frame.Cell
+	for _, line := range lines {
+		for len(line) > width {
+			out = append(out, line[:width])
+			line = line[width:]
+		}
+		out = append(out, line)
+	}
+
+	return out
+}
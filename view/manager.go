@@ -0,0 +1,179 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package view
+
+import (
+	"console"
+	"console/frame"
+	"errors"
+	"image"
+)
+
+// ErrQuit, returned by a layout function or a keybinding handler, stops
+// MainLoop without reporting an error.
+var ErrQuit = errors.New("view: quit")
+
+// keybinding identifies a registered handler: the View it applies to (empty
+// for any View) and the key and modifiers that trigger it.
+type keybinding struct {
+	view string
+	key  int32
+	mod  console.Modifier
+}
+
+// Manager owns a stack of Views, composites them into a root Frame each
+// pass, and dispatches input events read from a Console to keybinding
+// handlers.
+type Manager struct {
+	console  *console.Console
+	renderer *frame.Renderer
+
+	views   []*View
+	byName  map[string]*View
+	current string
+
+	bindings map[keybinding]func(*View) error
+	layout   func(*Manager) error
+}
+
+// NewManager creates a Manager that draws into c and lays out Views within
+// a root Frame bounded by r.
+func NewManager(c *console.Console, r image.Rectangle) *Manager {
+	return &Manager{
+		console:  c,
+		renderer: frame.NewRenderer(r),
+		byName:   make(map[string]*View),
+		bindings: make(map[keybinding]func(*View) error),
+	}
+}
+
+// Size returns the dimensions of the Manager's root Frame.
+func (m *Manager) Size() (width, height int) {
+	b := m.renderer.Back.Bounds
+	return b.Dx(), b.Dy()
+}
+
+// SetManagerFunc sets the function MainLoop calls before each pass to
+// create and position Views via SetView.
+func (m *Manager) SetManagerFunc(f func(*Manager) error) {
+	m.layout = f
+}
+
+// SetView creates the named View with bounds r if it doesn't already exist,
+// or repositions it to r if it does, and returns it. This is the usual way
+// a layout function populates a Manager.
+func (m *Manager) SetView(name string, r image.Rectangle) *View {
+	v, ok := m.byName[name]
+	if !ok {
+		v = newView(name, r)
+		m.byName[name] = v
+		m.views = append(m.views, v)
+		if m.current == "" {
+			m.current = name
+		}
+		return v
+	}
+
+	v.bounds = r
+	return v
+}
+
+// View returns the named View, or nil if no such View has been created.
+func (m *Manager) View(name string) *View {
+	return m.byName[name]
+}
+
+// CurrentView returns the View that keybindings without an explicit View
+// name apply to, or nil if no View has been created yet.
+func (m *Manager) CurrentView() *View {
+	return m.byName[m.current]
+}
+
+// SetCurrentView makes the named View the target of keybindings registered
+// without an explicit View name.
+func (m *Manager) SetCurrentView(name string) error {
+	if _, ok := m.byName[name]; !ok {
+		return errors.New("view: no such view " + name)
+	}
+	m.current = name
+	return nil
+}
+
+// SetKeybinding registers handler to run when key, held with modifiers mod,
+// is read while viewName is the current View. An empty viewName matches
+// regardless of which View is current.
+func (m *Manager) SetKeybinding(viewName string, key int32, mod console.Modifier, handler func(*View) error) {
+	m.bindings[keybinding{viewName, key, mod}] = handler
+}
+
+// MainLoop repeatedly lays out and draws the Manager's Views, then reads
+// and dispatches one event from its Console, until the layout function or a
+// keybinding handler returns ErrQuit or a non-nil error, or the Console
+// reports a read error, which MainLoop returns.
+func (m *Manager) MainLoop() error {
+	for {
+		if err := m.draw(); err != nil {
+			if err == ErrQuit {
+				return nil
+			}
+			return err
+		}
+
+		if err := m.dispatch(m.console.PollEvent()); err != nil {
+			if err == ErrQuit {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// draw runs the layout function and composites the resulting Views into the
+// root Frame, then flushes it to the Console.
+func (m *Manager) draw() error {
+	m.renderer.Back.Clear()
+
+	if m.layout != nil {
+		if err := m.layout(m); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range m.views {
+		composite(m.renderer.Back, v)
+	}
+
+	m.renderer.Flush(m.console)
+	return nil
+}
+
+// dispatch runs the keybinding handler registered for ev against the
+// current View, preferring a handler registered for the current View by
+// name over one registered for any View.
+func (m *Manager) dispatch(ev console.Event) error {
+	switch ev.Type {
+	case console.EventResize:
+		r := image.Rect(0, 0, ev.Width, ev.Height)
+		m.renderer.Resize(r)
+		return nil
+	case console.EventError:
+		return ev.Err
+	case console.EventKey:
+		key := ev.Key
+		if key == 0 {
+			key = int32(ev.Ch)
+		}
+
+		v := m.byName[m.current]
+		if h, ok := m.bindings[keybinding{m.current, key, ev.Mod}]; ok {
+			return h(v)
+		}
+		if h, ok := m.bindings[keybinding{"", key, ev.Mod}]; ok {
+			return h(v)
+		}
+	}
+
+	return nil
+}
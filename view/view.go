@@ -0,0 +1,165 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package view layers a gocui-style widget system on top of package frame: a
+// Manager owns a set of bordered, titled Views, composites them into a
+// frame.Frame each pass, and flushes the result with a frame.Renderer.
+package view
+
+import (
+	"console"
+	"console/frame"
+	"image"
+	"unicode/utf8"
+)
+
+// View is a bordered, titled panel holding its own scrollback of styled
+// lines. Views are created and positioned by a Manager's layout function via
+// Manager.SetView; they are never constructed directly.
+type View struct {
+	// Title is drawn into the View's top border. Wrap controls whether
+	// long lines are reflowed to the View's width (true) or clipped and
+	// scrolled horizontally via SetOrigin (false).
+	Title string
+	Wrap  bool
+
+	name   string
+	bounds image.Rectangle
+
+	lines    [][]frame.Cell
+	curColor console.Color
+
+	cursorX, cursorY int
+	originX, originY int
+}
+
+func newView(name string, r image.Rectangle) *View {
+	return &View{name: name, bounds: r}
+}
+
+// Name returns the name the View was created with.
+func (v *View) Name() string {
+	return v.name
+}
+
+// Bounds returns the View's position and size within the Manager's root
+// Frame, border included.
+func (v *View) Bounds() image.Rectangle {
+	return v.bounds
+}
+
+// Write appends p to the View's scrollback in the View's current colour,
+// starting a new line at each '\n'. It implements io.Writer, so a View can
+// be used directly as the destination of fmt.Fprintf and similar.
+func (v *View) Write(p []byte) (int, error) {
+	if len(v.lines) == 0 {
+		v.lines = append(v.lines, nil)
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		p = p[size:]
+
+		if r == '\n' {
+			v.lines = append(v.lines, nil)
+			continue
+		}
+
+		last := len(v.lines) - 1
+		v.lines[last] = append(v.lines[last], frame.Cell{R: r, C: v.curColor})
+	}
+
+	return n, nil
+}
+
+// WriteText appends vals to the View's scrollback as a single line.
+// Colours are added by interspersing console.Color structs between
+// strings, exactly as Frame.PutText does.
+func (v *View) WriteText(vals ...interface{}) {
+	if len(v.lines) == 0 {
+		v.lines = append(v.lines, nil)
+	}
+	last := len(v.lines) - 1
+
+	for _, val := range vals {
+		switch o := val.(type) {
+		case string:
+			for _, r := range o {
+				v.lines[last] = append(v.lines[last], frame.Cell{R: r, C: v.curColor})
+			}
+		case console.Color:
+			v.curColor = o
+		default:
+			panic("View.WriteText accepts data of only type string and console.Color.")
+		}
+	}
+}
+
+// Clear discards the View's scrollback and resets its cursor and origin.
+func (v *View) Clear() {
+	v.lines = nil
+	v.cursorX, v.cursorY = 0, 0
+	v.originX, v.originY = 0, 0
+}
+
+// SetCursor moves the View's cursor to x,y, clamping it to the View's
+// interior.
+func (v *View) SetCursor(x, y int) {
+	v.cursorX, v.cursorY = clamp(x, v.innerWidth()), clamp(y, v.innerHeight())
+}
+
+// Cursor returns the View's current cursor position, relative to its
+// interior.
+func (v *View) Cursor() (x, y int) {
+	return v.cursorX, v.cursorY
+}
+
+// SetOrigin scrolls the View so that x,y is the top-left Cell of its
+// scrollback shown in its interior. Negative coordinates are clamped to 0
+// and y to at most the number of scrollback lines; x has no upper limit.
+func (v *View) SetOrigin(x, y int) {
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	if y > len(v.lines) {
+		y = len(v.lines)
+	}
+	v.originX, v.originY = x, y
+}
+
+// Origin returns the View's current scroll origin.
+func (v *View) Origin() (x, y int) {
+	return v.originX, v.originY
+}
+
+func (v *View) innerWidth() int {
+	if w := v.bounds.Dx() - 2; w > 0 {
+		return w
+	}
+	return 0
+}
+
+func (v *View) innerHeight() int {
+	if h := v.bounds.Dy() - 2; h > 0 {
+		return h
+	}
+	return 0
+}
+
+func clamp(v, max int) int {
+	if v < 0 {
+		return 0
+	}
+	if max == 0 {
+		return 0
+	}
+	if v >= max {
+		return max - 1
+	}
+	return v
+}
@@ -0,0 +1,80 @@
+// Copyright 2013 Daniel Jo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package view
+
+import (
+	"console/frame"
+	"image"
+	"testing"
+)
+
+// TestClamp checks clamp's bounds: negative values floor to 0, values at or
+// beyond max saturate to max-1, and a max of 0 always yields 0.
+func TestClamp(t *testing.T) {
+	tests := []struct {
+		v, max, want int
+	}{
+		{-5, 10, 0},
+		{0, 10, 0},
+		{5, 10, 5},
+		{9, 10, 9},
+		{10, 10, 9},
+		{100, 10, 9},
+		{5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := clamp(tt.v, tt.max); got != tt.want {
+			t.Errorf("clamp(%d, %d) = %d, want %d", tt.v, tt.max, got, tt.want)
+		}
+	}
+}
+
+// TestSetCursorClampsToInterior checks that SetCursor keeps the cursor
+// within the View's interior (bounds shrunk by the 1-Cell border on each
+// side), not its full bounds.
+func TestSetCursorClampsToInterior(t *testing.T) {
+	v := newView("test", image.Rect(0, 0, 5, 4))
+
+	v.SetCursor(100, 100)
+	if x, y := v.Cursor(); x != 2 || y != 1 {
+		t.Errorf("Cursor() = %d,%d, want 2,1", x, y)
+	}
+
+	v.SetCursor(-5, -5)
+	if x, y := v.Cursor(); x != 0 || y != 0 {
+		t.Errorf("Cursor() = %d,%d, want 0,0", x, y)
+	}
+}
+
+// TestWrapLines checks that wrapLines splits lines longer than width into
+// width-sized chunks, leaves shorter lines alone, and passes lines through
+// unchanged when width is non-positive.
+func TestWrapLines(t *testing.T) {
+	mkLine := func(n int) []frame.Cell {
+		line := make([]frame.Cell, n)
+		for i := range line {
+			line[i] = frame.Cell{R: rune('a' + i)}
+		}
+		return line
+	}
+
+	lines := [][]frame.Cell{mkLine(7), mkLine(2)}
+	got := wrapLines(lines, 3)
+
+	wantLens := []int{3, 3, 1, 2}
+	if len(got) != len(wantLens) {
+		t.Fatalf("wrapLines produced %d lines, want %d", len(got), len(wantLens))
+	}
+	for i, want := range wantLens {
+		if len(got[i]) != want {
+			t.Errorf("line %d has %d Cells, want %d", i, len(got[i]), want)
+		}
+	}
+
+	if got := wrapLines(lines, 0); len(got) != len(lines) {
+		t.Errorf("wrapLines with width 0 = %d lines, want %d unchanged", len(got), len(lines))
+	}
+}